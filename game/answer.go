@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Answer is the result of testing a code against the verifier at index Verifier of a game's cards
+type Answer struct {
+	Code     []int `json:"code"`
+	Verifier int   `json:"verifier"`
+	Valid    bool  `json:"valid"`
+}
+
+// String formats the answer the way ParseAnswer reads it, e.g. "245 2 y" for code 245 passing the second verifier
+func (a Answer) String() string {
+	result := "n"
+	if a.Valid {
+		result = "y"
+	}
+
+	return fmt.Sprintf("%v %v %v", CodeString(a.Code), a.Verifier+1, result)
+}
+
+// CodeString writes a code as its digits, e.g. 245
+func CodeString(code []int) string {
+	digits := ""
+	for _, digit := range code {
+		digits += strconv.Itoa(digit)
+	}
+
+	return digits
+}
+
+// ParseAnswer reads an answer written as a code, a verifier numbered from 1 and y or n, separated by spaces, commas or
+// colons. The verifier is checked against the number of cards in the game.
+func ParseAnswer(text string, nCards int) (Answer, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ':'
+	})
+	if len(fields) != 3 {
+		return Answer{}, fmt.Errorf("expected code, verifier and y/n: %q", text)
+	}
+
+	code, err := ParseCode(fields[0])
+	if err != nil {
+		return Answer{}, err
+	}
+	answer := Answer{Code: code}
+
+	verifier, err := strconv.Atoi(fields[1])
+	if err != nil || verifier < 1 || verifier > nCards {
+		return Answer{}, fmt.Errorf("verifier must be 1 to %v: %q", nCards, fields[1])
+	}
+	answer.Verifier = verifier - 1
+
+	switch strings.ToLower(fields[2]) {
+	case "y", "yes", "true", "t":
+		answer.Valid = true
+	case "n", "no", "false", "f":
+		answer.Valid = false
+	default:
+		return Answer{}, fmt.Errorf("answer must be y or n: %q", fields[2])
+	}
+
+	return answer, nil
+}
+
+// ParseCode reads a code written as its digits, e.g. "245"
+func ParseCode(text string) ([]int, error) {
+	var code []int
+	for _, digit := range text {
+		if digit < '1' || digit > '5' {
+			return nil, fmt.Errorf("code digits must be 1 to 5: %q", text)
+		}
+		code = append(code, int(digit-'0'))
+	}
+	if len(code) != 3 {
+		return nil, fmt.Errorf("code must have 3 digits: %q", text)
+	}
+
+	return code, nil
+}
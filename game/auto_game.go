@@ -2,6 +2,8 @@ package game
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"slices"
 	"strings"
 	"sync"
@@ -15,6 +17,15 @@ type AutoGame struct {
 	actualCode      []int
 	playerStats     map[Player]*PlayerMoves
 	playerStatsLock sync.Mutex
+
+	// rules every player's moves are checked against
+	rules Rules
+
+	// lieProbability is the chance of each answer being wrong, for testing solvers against mistaken answers. Each
+	// player draws its lies from its own source, so players playing at the same time don't change each other's lies.
+	lieProbability float64
+	lieSeed        int64
+	lies           map[Player]*rand.Rand
 }
 
 func NewAutoGame(verifierCards []*verifiers.VerifierCard, actualVerifiers []*verifiers.Verifier, actualCode []int) *AutoGame {
@@ -23,9 +34,53 @@ func NewAutoGame(verifierCards []*verifiers.VerifierCard, actualVerifiers []*ver
 		actualVerfiers: actualVerifiers,
 		actualCode:     actualCode,
 		playerStats:    make(map[Player]*PlayerMoves),
+		rules:          DefaultRules,
 	}
 }
 
+// SetRules changes the rules of the game, before anyone plays it
+func (g *AutoGame) SetRules(rules Rules) {
+	g.playerStatsLock.Lock()
+	defer g.playerStatsLock.Unlock()
+
+	g.rules = rules
+}
+
+func (g *AutoGame) Rules() Rules {
+	g.playerStatsLock.Lock()
+	defer g.playerStatsLock.Unlock()
+
+	return g.rules
+}
+
+// SetLieProbability makes the game give the wrong answer to questions with the given probability. The seed and the
+// player's name make the lies repeatable, a player asking the same questions is told the same lies.
+func (g *AutoGame) SetLieProbability(probability float64, seed int64) {
+	g.playerStatsLock.Lock()
+	defer g.playerStatsLock.Unlock()
+
+	g.lieProbability = probability
+	g.lieSeed = seed
+	g.lies = make(map[Player]*rand.Rand)
+}
+
+// lie returns true if the player's next answer is wrong, the caller holds the player stats lock
+func (g *AutoGame) lie(player Player) bool {
+	if g.lieProbability <= 0 {
+		return false
+	}
+
+	random, ok := g.lies[player]
+	if !ok {
+		name := fnv.New64a()
+		name.Write([]byte(player.GetPlayerName()))
+		random = rand.New(rand.NewSource(g.lieSeed ^ int64(name.Sum64())))
+		g.lies[player] = random
+	}
+
+	return random.Float64() < g.lieProbability
+}
+
 func (g *AutoGame) String() string {
 	description := ""
 	for cardIndex, card := range g.verifierCards {
@@ -43,52 +98,91 @@ func (g *AutoGame) GetVerifierCards() []*verifiers.VerifierCard {
 	return g.verifierCards
 }
 
-func (g *AutoGame) AskQuestion(player Player, code []int, verifier int) bool {
+// Solution returns the secret code and verifiers of the game
+func (g *AutoGame) Solution() Solution {
+	return Solution{
+		Code:      g.actualCode,
+		Verifiers: g.actualVerfiers,
+	}
+}
+
+// Stats returns the moves made by each player so far
+func (g *AutoGame) Stats() map[Player]*PlayerMoves {
 	g.playerStatsLock.Lock()
 	defer g.playerStatsLock.Unlock()
 
-	playerStats := g.playerStats[player]
-	if playerStats == nil {
-		playerStats = &PlayerMoves{player: player}
-		g.playerStats[player] = playerStats
+	stats := make(map[Player]*PlayerMoves, len(g.playerStats))
+	for player, moves := range g.playerStats {
+		stats[player] = moves
 	}
 
-	if err := playerStats.askedQuestion(code, g.verifierCards[verifier]); err != nil {
-		fmt.Println(err)
-		return false
+	return stats
+}
+
+// StartRound commits the player to testing code until EndRound
+func (g *AutoGame) StartRound(player Player, code []int) error {
+	if err := CheckCode(code); err != nil {
+		return err
 	}
 
-	return g.actualVerfiers[verifier].Verify(code...)
+	g.playerStatsLock.Lock()
+	defer g.playerStatsLock.Unlock()
+
+	return g.moves(player).startedRound(code)
 }
 
-func (g *AutoGame) MakeGuess(player Player, code []int) (correct bool) {
+func (g *AutoGame) EndRound(player Player) error {
 	g.playerStatsLock.Lock()
 	defer g.playerStatsLock.Unlock()
 
-	playerStats := g.playerStats[player]
-	if playerStats == nil {
-		playerStats = &PlayerMoves{player: player}
-		g.playerStats[player] = playerStats
+	return g.moves(player).endedRound()
+}
+
+func (g *AutoGame) AskQuestion(player Player, code []int, verifier int) (bool, error) {
+	if err := CheckMove(code, verifier, len(g.verifierCards)); err != nil {
+		return false, err
 	}
 
-	correct = true
-	if len(code) != len(g.actualCode) {
-		correct = false
-	} else {
-		for i := range code {
-			if code[i] != g.actualCode[i] {
-				correct = false
-				break
-			}
-		}
+	g.playerStatsLock.Lock()
+	defer g.playerStatsLock.Unlock()
+
+	if err := g.moves(player).askedQuestion(code, verifier, g.verifierCards[verifier], g.rules); err != nil {
+		return false, err
 	}
 
-	if err := playerStats.madeGuess(code, correct); err != nil {
-		fmt.Println(err)
-		return false
+	valid := g.actualVerfiers[verifier].Verify(code...)
+	if g.lie(player) {
+		return !valid, nil
+	}
+
+	return valid, nil
+}
+
+func (g *AutoGame) MakeGuess(player Player, code []int) (bool, error) {
+	if err := CheckCode(code); err != nil {
+		return false, err
+	}
+
+	g.playerStatsLock.Lock()
+	defer g.playerStatsLock.Unlock()
+
+	correct := slices.Equal(code, g.actualCode)
+	if err := g.moves(player).madeGuess(code, correct, g.rules); err != nil {
+		return false, err
+	}
+
+	return correct, nil
+}
+
+// moves returns the moves of the player, the lock must be held
+func (g *AutoGame) moves(player Player) *PlayerMoves {
+	playerStats := g.playerStats[player]
+	if playerStats == nil {
+		playerStats = &PlayerMoves{player: player}
+		g.playerStats[player] = playerStats
 	}
 
-	return
+	return playerStats
 }
 
 func (g *AutoGame) Rank() [][]Player {
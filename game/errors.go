@@ -0,0 +1,80 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrRuleViolation is returned for moves the rules don't allow, such as a code that isn't three digits from 1 to 5
+	ErrRuleViolation = errors.New("rule violation")
+	// ErrInvalidVerifier is returned when asking about a verifier the game doesn't have
+	ErrInvalidVerifier = errors.New("invalid verifier index")
+	// ErrAlreadyGuessed is returned for moves made by a player who has already guessed
+	ErrAlreadyGuessed = errors.New("player has already guessed")
+)
+
+// TransportError is returned when a game played somewhere else can't be reached or doesn't answer
+type TransportError struct {
+	Op  string
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Op + " : " + e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// errorCodes name the errors that are sent between servers and remote games
+var errorCodes = map[string]error{
+	"rule_violation":   ErrRuleViolation,
+	"invalid_verifier": ErrInvalidVerifier,
+	"already_guessed":  ErrAlreadyGuessed,
+}
+
+// ErrorCode returns the name a server sends for err, empty for errors a remote game can't do anything about
+func ErrorCode(err error) string {
+	for code, codeErr := range errorCodes {
+		if errors.Is(err, codeErr) {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// errorFromCode returns the error a server sent by name, nil for unknown names
+func errorFromCode(code string, message string) error {
+	if codeErr, ok := errorCodes[code]; ok {
+		return fmt.Errorf("%w: %v", codeErr, message)
+	}
+
+	return nil
+}
+
+// CheckMove returns an error if the code and verifier index can't be asked about in a game with nCards verifiers
+func CheckMove(code []int, verifierIndex int, nCards int) error {
+	if verifierIndex < 0 || verifierIndex >= nCards {
+		return fmt.Errorf("%w: %v in a game with %v verifiers", ErrInvalidVerifier, verifierIndex, nCards)
+	}
+
+	return CheckCode(code)
+}
+
+// CheckCode returns an error unless code is three digits from 1 to 5
+func CheckCode(code []int) error {
+	if len(code) != 3 {
+		return fmt.Errorf("%w: code %v must have 3 digits", ErrRuleViolation, code)
+	}
+
+	for _, digit := range code {
+		if digit < 1 || digit > 5 {
+			return fmt.Errorf("%w: code %v digits must be 1 to 5", ErrRuleViolation, code)
+		}
+	}
+
+	return nil
+}
@@ -10,8 +10,17 @@ import (
 type Game interface {
 	fmt.Stringer
 	GetVerifierCards() []*verifiers.VerifierCard
-	AskQuestion(player Player, code []int, verifier int) bool
-	MakeGuess(player Player, code []int) bool
+	// Rules returns the limits the game puts on the moves of every player
+	Rules() Rules
+	// StartRound commits the player to testing code until EndRound, a round must be ended before the next one starts
+	// and, unless the rules allow it, before guessing
+	StartRound(player Player, code []int) error
+	EndRound(player Player) error
+	// AskQuestion returns whether code passes the verifier at index verifier, answers given with an error are
+	// meaningless
+	AskQuestion(player Player, code []int, verifier int) (bool, error)
+	// MakeGuess returns whether code is the secret code
+	MakeGuess(player Player, code []int) (bool, error)
 	// Rank returns the players in order of their performance. Ties go into the same slice
 	Rank() [][]Player
 }
@@ -0,0 +1,328 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+type testPlayer string
+
+func (p testPlayer) GetPlayerName() string {
+	return string(p)
+}
+
+// testGame is played with cards 4, 9, 11 and 14, its code is 221
+func testGame(rules Rules) *AutoGame {
+	cards := []*verifiers.VerifierCard{&verifiers.Cards[3], &verifiers.Cards[8], &verifiers.Cards[10], &verifiers.Cards[13]}
+	actualVerifiers := []*verifiers.Verifier{cards[0].Verifiers[0], cards[1].Verifiers[0], cards[2].Verifiers[1], cards[3].Verifiers[2]}
+	g := NewAutoGame(cards, actualVerifiers, []int{2, 2, 1})
+	g.SetRules(rules)
+
+	return g
+}
+
+// move is made by the player in the game, returning the error the game gives
+type move func(g Game, player Player) error
+
+func start(code ...int) move {
+	return func(g Game, player Player) error {
+		return g.StartRound(player, code)
+	}
+}
+
+func ask(verifier int, code ...int) move {
+	return func(g Game, player Player) error {
+		_, err := g.AskQuestion(player, code, verifier)
+		return err
+	}
+}
+
+func end() move {
+	return func(g Game, player Player) error {
+		return g.EndRound(player)
+	}
+}
+
+func guess(code ...int) move {
+	return func(g Game, player Player) error {
+		_, err := g.MakeGuess(player, code)
+		return err
+	}
+}
+
+func TestRoundRulesViolations(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		moves []move
+	}{
+		{"same verifier twice in a round", DefaultRules, []move{start(1, 2, 3), ask(0, 1, 2, 3), ask(0, 1, 2, 3)}},
+		{"more questions than the round allows", Rules{QuestionsPerRound: 2}, []move{start(1, 2, 3), ask(0, 1, 2, 3), ask(1, 1, 2, 3), ask(2, 1, 2, 3)}},
+		{"starting a round before ending the last", DefaultRules, []move{start(1, 2, 3), ask(0, 1, 2, 3), start(2, 2, 2)}},
+		{"guessing mid round", DefaultRules, []move{start(1, 2, 3), ask(0, 1, 2, 3), guess(2, 2, 1)}},
+		{"asking before a round starts", DefaultRules, []move{ask(0, 1, 2, 3)}},
+		{"asking about another code than the round's", DefaultRules, []move{start(1, 2, 3), ask(0, 2, 2, 2)}},
+		{"ending a round that wasn't started", DefaultRules, []move{end()}},
+		{"code that isn't three digits from 1 to 5", DefaultRules, []move{start(1, 2, 6)}},
+	}
+
+	for _, test := range tests {
+		g := testGame(test.rules)
+		player := testPlayer("player")
+		for i, m := range test.moves {
+			err := m(g, player)
+			if i < len(test.moves)-1 && err != nil {
+				t.Fatalf("%v: move %v: %v", test.name, i+1, err)
+			} else if i == len(test.moves)-1 && !errors.Is(err, ErrRuleViolation) {
+				t.Fatalf("%v: expected a rule violation, got %v", test.name, err)
+			}
+		}
+	}
+}
+
+func TestRoundRulesAllowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		moves []move
+	}{
+		{"same verifier in the next round", DefaultRules, []move{start(1, 2, 3), ask(0, 1, 2, 3), end(), start(1, 2, 3), ask(0, 1, 2, 3)}},
+		{"guessing mid round when the rules allow it", Rules{QuestionsPerRound: 3, GuessMidRound: true}, []move{start(1, 2, 3), ask(0, 1, 2, 3), guess(2, 2, 1)}},
+		{"every verifier without a question limit", Rules{}, []move{start(1, 2, 3), ask(0, 1, 2, 3), ask(1, 1, 2, 3), ask(2, 1, 2, 3), ask(3, 1, 2, 3)}},
+		{"guessing without testing a code", DefaultRules, []move{guess(2, 2, 1)}},
+	}
+
+	for _, test := range tests {
+		g := testGame(test.rules)
+		player := testPlayer("player")
+		for i, m := range test.moves {
+			if err := m(g, player); err != nil {
+				t.Fatalf("%v: move %v: %v", test.name, i+1, err)
+			}
+		}
+	}
+}
+
+func TestRoundsCountCodesTested(t *testing.T) {
+	g := testGame(DefaultRules)
+	player := testPlayer("player")
+	for i, m := range []move{start(1, 2, 3), ask(0, 1, 2, 3), ask(1, 1, 2, 3), end(), start(2, 2, 2), end(), start(3, 3, 3), ask(2, 3, 3, 3), end()} {
+		if err := m(g, player); err != nil {
+			t.Fatalf("move %v: %v", i+1, err)
+		}
+	}
+
+	moves := g.Stats()[player]
+	if moves.CodesTested() != 2 || moves.QuestionsAsked() != 3 {
+		t.Fatalf("expected 2 codes and 3 questions, a round without questions doesn't test its code: %v codes, %v questions", moves.CodesTested(), moves.QuestionsAsked())
+	}
+}
+
+// replayLog plays the moves of a game log again against the puzzle of its header, failing the test unless every move
+// gets the answer and error it was logged with. It returns the replayed game and its players by name.
+func replayLog(t *testing.T, entries []LogEntry) (*AutoGame, map[string]Player) {
+	t.Helper()
+	if len(entries) == 0 || entries[0].Kind != LogGame {
+		t.Fatalf("expected the log to start with the puzzle: %+v", entries)
+	}
+
+	header := entries[0]
+	cards := make([]*verifiers.VerifierCard, len(header.Cards))
+	secretVerifiers := make([]*verifiers.Verifier, len(header.Cards))
+	for i, cardNumber := range header.Cards {
+		card, err := verifiers.CardFromNumber(cardNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cards[i] = card
+		secretVerifiers[i] = card.Verifiers[header.Verifiers[i]]
+	}
+	replayed := NewAutoGame(cards, secretVerifiers, header.Code)
+	replayed.SetRules(*header.Rules)
+
+	players := make(map[string]Player)
+	for _, entry := range entries[1:] {
+		if _, ok := players[entry.Player]; !ok {
+			players[entry.Player] = testPlayer(entry.Player)
+		}
+		player := players[entry.Player]
+
+		var result bool
+		var err error
+		switch entry.Kind {
+		case LogStartRound:
+			err = replayed.StartRound(player, entry.Code)
+		case LogQuestion:
+			result, err = replayed.AskQuestion(player, entry.Code, entry.Verifier)
+		case LogEndRound:
+			err = replayed.EndRound(player)
+		case LogGuess:
+			result, err = replayed.MakeGuess(player, entry.Code)
+		default:
+			t.Fatalf("unknown move %+v", entry)
+		}
+
+		errText := ""
+		if err != nil {
+			errText = err.Error()
+		}
+		if result != entry.Result || errText != entry.Error {
+			t.Fatalf("%+v replayed as %v, error %q", entry, result, errText)
+		}
+	}
+
+	return replayed, players
+}
+
+func TestRecordedGameReplays(t *testing.T) {
+	played := testGame(DefaultRules)
+	logged := bytes.Buffer{}
+	recorded := NewRecorder(&logged).Record(played)
+
+	alice, bob := testPlayer("alice"), testPlayer("bob")
+	moves := []struct {
+		player Player
+		move   move
+	}{
+		{alice, start(1, 2, 3)}, {alice, ask(0, 1, 2, 3)}, {bob, guess(1, 1, 1)}, {alice, ask(1, 1, 2, 3)},
+		{alice, ask(1, 1, 2, 3)}, {alice, end()}, {alice, start(2, 2, 1)}, {alice, ask(2, 2, 2, 1)}, {alice, end()},
+		{alice, guess(2, 2, 1)},
+	}
+	for _, m := range moves {
+		// The rejected moves are logged as well
+		m.move(recorded, m.player)
+	}
+
+	entries, err := ReadLog(&logged)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != len(moves)+1 {
+		t.Fatalf("expected the puzzle and %v moves, read %v entries", len(moves), len(entries))
+	} else if entries[5].Error == "" || entries[5].Round != 1 || entries[7].Round != 2 {
+		t.Fatalf("expected the verifier asked twice to be logged with its error in round 1: %+v", entries)
+	}
+
+	replayed, players := replayLog(t, entries)
+	for _, player := range []testPlayer{alice, bob} {
+		want, got := played.Stats()[player], replayed.Stats()[players[string(player)]]
+		wantCorrect, wantGuessed := want.GuessedCorrectly()
+		gotCorrect, gotGuessed := got.GuessedCorrectly()
+		if want.CodesTested() != got.CodesTested() || want.QuestionsAsked() != got.QuestionsAsked() || wantCorrect != gotCorrect || wantGuessed != gotGuessed {
+			t.Fatalf("%v replayed as %+v, played as %+v", player, got, want)
+		}
+	}
+}
+
+func TestTruncatedLog(t *testing.T) {
+	logged := bytes.Buffer{}
+	recorded := NewRecorder(&logged).Record(testGame(DefaultRules))
+	start(1, 2, 3)(recorded, testPlayer("alice"))
+	ask(0, 1, 2, 3)(recorded, testPlayer("alice"))
+
+	complete := logged.Bytes()
+	if _, err := ReadLog(bytes.NewReader(complete[:len(complete)-10])); err == nil {
+		t.Fatal("expected an error reading a log cut off in the middle of a line")
+	}
+
+	// A log cut off between lines is read up to where it stops
+	lines := bytes.SplitAfter(complete, []byte("\n"))
+	entries, err := ReadLog(bytes.NewReader(bytes.Join(lines[:2], nil)))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 || entries[1].Kind != LogStartRound {
+		t.Fatalf("expected the puzzle and the round started: %+v", entries)
+	}
+}
+
+// testPuzzle is the puzzle of testGame, cardNumber 9 can be given as an XTREAM card number that includes card 9
+func testPuzzle(cardNumber9 int) Puzzle {
+	rules := Rules{QuestionsPerRound: 2, GuessMidRound: true}
+	return Puzzle{Cards: []int{4, cardNumber9, 11, 14}, Verifiers: []int{0, 0, 1, 2}, Code: []int{2, 2, 1}, Rules: &rules}
+}
+
+func TestPuzzlesSaveAndLoad(t *testing.T) {
+	puzzles := []Puzzle{testPuzzle(9), testPuzzle(9020)}
+	games := make([]Game, len(puzzles))
+	for i, puzzle := range puzzles {
+		puzzleGame, err := PuzzleGame(puzzle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		games[i] = puzzleGame
+	}
+
+	filename := filepath.Join(t.TempDir(), "puzzles.json")
+	if err := SavePuzzles(filename, games); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPuzzles(filename)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(loaded) != len(puzzles) {
+		t.Fatalf("saved %v puzzles, loaded %v", len(puzzles), len(loaded))
+	}
+
+	for i, loadedGame := range loaded {
+		if got := NewPuzzle(loadedGame.(*AutoGame)); !reflect.DeepEqual(got, puzzles[i]) {
+			t.Fatalf("saved %+v, loaded %+v", puzzles[i], got)
+		} else if len(loadedGame.GetVerifierCards()[1].Verifiers) != len(games[i].GetVerifierCards()[1].Verifiers) {
+			t.Fatalf("puzzle %v loaded card %v with other verifiers", i+1, puzzles[i].Cards[1])
+		}
+	}
+}
+
+func TestPuzzleWithoutRulesPlaysDefaultRules(t *testing.T) {
+	puzzle := testPuzzle(9)
+	puzzle.Rules = nil
+	puzzleGame, err := PuzzleGame(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	} else if puzzleGame.Rules() != DefaultRules {
+		t.Fatalf("expected the default rules, got %+v", puzzleGame.Rules())
+	}
+}
+
+func TestPuzzleSecretMustBeOnlySolution(t *testing.T) {
+	wrongCode := testPuzzle(9)
+	wrongCode.Code = []int{1, 1, 1}
+
+	// Without card 14, more codes pass the other verifiers
+	notUnique := testPuzzle(9)
+	notUnique.Cards, notUnique.Verifiers = notUnique.Cards[:3], notUnique.Verifiers[:3]
+
+	// Card 9 doesn't rule out any code the other cards don't
+	notNeeded := testPuzzle(9)
+	notNeeded.Cards = append(notNeeded.Cards, 9)
+	notNeeded.Verifiers = append(notNeeded.Verifiers, 0)
+
+	for name, puzzle := range map[string]Puzzle{"wrong code": wrongCode, "not unique": notUnique, "not needed": notNeeded} {
+		if _, err := PuzzleGame(puzzle); err == nil {
+			t.Fatalf("%v: expected %+v to be rejected", name, puzzle)
+		}
+	}
+}
+
+func TestLoadMalformedPuzzles(t *testing.T) {
+	for name, contents := range map[string]string{
+		"not json":         `{"version": 1, "puzzles": [`,
+		"other version":    `{"version": 2, "puzzles": []}`,
+		"unknown card":     `{"version": 1, "puzzles": [{"cards": [4, 99, 11, 14], "verifiers": [0, 0, 1, 2], "code": [2, 2, 1]}]}`,
+		"missing secret":   `{"version": 1, "puzzles": [{"cards": [4, 9, 11, 14], "verifiers": [0, 0, 1], "code": [2, 2, 1]}]}`,
+		"no such verifier": `{"version": 1, "puzzles": [{"cards": [4, 9, 11, 14], "verifiers": [0, 0, 1, 5], "code": [2, 2, 1]}]}`,
+		"bad code":         `{"version": 1, "puzzles": [{"cards": [4, 9, 11, 14], "verifiers": [0, 0, 1, 2], "code": [2, 2]}]}`,
+	} {
+		filename := filepath.Join(t.TempDir(), "puzzles.json")
+		if err := os.WriteFile(filename, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPuzzles(filename); err == nil {
+			t.Fatalf("%v: expected an error loading %v", name, contents)
+		}
+	}
+}
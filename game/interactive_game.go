@@ -1,8 +1,14 @@
 package game
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/caseymerrill/turingsolver/verifiers"
@@ -12,6 +18,49 @@ type InteractiveGame struct {
 	cards []*verifiers.VerifierCard
 }
 
+// ErrQuit is returned by an InteractiveGame when asked to quit
+var ErrQuit = errors.New("quit")
+
+// AnswersEdited is returned by an InteractiveGame when asked to change the answers given so far, the player should
+// start over from Answers
+type AnswersEdited struct {
+	Answers []Answer
+}
+
+func (e *AnswersEdited) Error() string {
+	return "answers edited"
+}
+
+// history is implemented by players that can show what they know at the prompt
+type history interface {
+	Solutions() []Solution
+	Answers() []Answer
+}
+
+// stdin is shared by every interactive game so input read ahead by one isn't lost to the next
+var stdin = bufio.NewScanner(os.Stdin)
+
+// ReadLine reads the next line of stdin through the scanner interactive games read their answers with, so answers typed
+// or piped ahead of the game aren't lost. It returns io.EOF at the end of the input.
+func ReadLine() (string, error) {
+	if !stdin.Scan() {
+		if err := stdin.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	return stdin.Text(), nil
+}
+
+const promptHelp = `Commands:
+  y, n        Answer the question
+  undo        Take back the last answer
+  change <n>  Flip answer <n> of the history
+  show        Show the solutions that are still possible
+  history     Show the answers so far
+  quit        Stop playing`
+
 func NewInteractiveGame(cards []*verifiers.VerifierCard) Game {
 	return &InteractiveGame{
 		cards: cards,
@@ -27,14 +76,32 @@ func (g *InteractiveGame) GetVerifierCards() []*verifiers.VerifierCard {
 	return g.cards
 }
 
-func (g *InteractiveGame) AskQuestion(player Player, code []int, verifierIndex int) bool {
+// Rules are the board game's, whoever runs the game at the table enforces them
+func (g *InteractiveGame) Rules() Rules {
+	return DefaultRules
+}
+
+func (g *InteractiveGame) StartRound(player Player, code []int) error {
+	return CheckCode(code)
+}
+
+func (g *InteractiveGame) EndRound(player Player) error {
+	return nil
+}
+
+// AskQuestion returns ErrQuit or an *AnswersEdited when a command stops the game instead of answering
+func (g *InteractiveGame) AskQuestion(player Player, code []int, verifierIndex int) (bool, error) {
+	if err := CheckMove(code, verifierIndex, len(g.cards)); err != nil {
+		return false, err
+	}
+
 	fmt.Printf("Test code: %v agains verifier %v: %v\n", code, verifierIndex+1, g.cards[verifierIndex])
-	return getBoolStdin()
+	return prompt(player)
 }
 
-func (g *InteractiveGame) MakeGuess(player Player, code []int) bool {
-	fmt.Printf("Make a guess: %v", code)
-	return getBoolStdin()
+func (g *InteractiveGame) MakeGuess(player Player, code []int) (bool, error) {
+	fmt.Printf("Make a guess: %v\n", code)
+	return prompt(player)
 }
 
 func (g *InteractiveGame) String() string {
@@ -56,22 +123,82 @@ func (g *InteractiveGame) Rank() [][]Player {
 	return nil
 }
 
-func getBoolStdin() bool {
+// prompt reads answers and commands until the question is answered or a command stops the game
+func prompt(player Player) (bool, error) {
 	for {
-		fmt.Print("(y/n): ")
-		var input string
-		_, err := fmt.Scanln(&input)
-		if err != nil {
-			log.Fatal(err)
+		fmt.Print("(y/n, ? for commands): ")
+		if !stdin.Scan() {
+			if err := stdin.Err(); err != nil {
+				return false, fmt.Errorf("reading input : %w", err)
+			}
+			return false, ErrQuit
+		}
+
+		fields := strings.Fields(strings.ToLower(stdin.Text()))
+		if len(fields) == 0 {
+			continue
 		}
 
-		switch strings.ToLower(input) {
+		switch fields[0] {
 		case "y", "yes", "true", "1":
-			return true
+			return true, nil
 		case "n", "no", "false", "0":
-			return false
+			return false, nil
+		case "?", "help":
+			fmt.Println(promptHelp)
+		case "q", "quit", "exit":
+			return false, ErrQuit
+		case "u", "undo":
+			answers := playerAnswers(player)
+			if len(answers) == 0 {
+				fmt.Println("Nothing to undo")
+				continue
+			}
+
+			fmt.Println("Undoing", answers[len(answers)-1])
+			return false, &AnswersEdited{Answers: answers[:len(answers)-1]}
+		case "c", "change":
+			answers := playerAnswers(player)
+			if len(fields) != 2 {
+				fmt.Println("Usage: change <n>, where <n> is the number of the answer in the history")
+				continue
+			}
+
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 1 || n > len(answers) {
+				fmt.Printf("No answer %v, there are %v answers\n", fields[1], len(answers))
+				continue
+			}
+
+			answers[n-1].Valid = !answers[n-1].Valid
+			fmt.Println("Changing answer", n, "to", answers[n-1])
+			return false, &AnswersEdited{Answers: answers}
+		case "s", "show":
+			if known, ok := player.(history); ok {
+				fmt.Printf("%v solutions:\n", len(known.Solutions()))
+				for _, solution := range known.Solutions() {
+					fmt.Printf("  %v\n", solution)
+				}
+			}
+		case "h", "history":
+			answers := playerAnswers(player)
+			if len(answers) == 0 {
+				fmt.Println("No answers yet")
+			}
+			for i, answer := range answers {
+				fmt.Printf("  %v: %v\n", i+1, answer)
+			}
 		default:
-			fmt.Println("Unrecognized input")
+			fmt.Println("Unrecognized input, ? for commands")
 		}
 	}
 }
+
+// playerAnswers returns a copy of the answers the player has been given
+func playerAnswers(player Player) []Answer {
+	if known, ok := player.(history); ok {
+		return slices.Clone(known.Answers())
+	}
+
+	return nil
+}
@@ -8,52 +8,98 @@ import (
 	"github.com/caseymerrill/turingsolver/verifiers"
 )
 
-const questionsPerCode = 3
-
 type Player interface {
 	// GetPlayerName returns the name of the player
 	GetPlayerName() string
 }
 
 type PlayerMoves struct {
-	player                 Player
-	codesTested            int
-	questionsAskedThisCode int
-	questionsAsked         []Question
+	player         Player
+	codesTested    int
+	questionsAsked []Question
+
+	// roundCode is the code of the round in progress, nil between rounds
+	roundCode               []int
+	questionsAskedThisRound int
 
 	codeGuessed      []int
 	guessedCorrectly optional.Optional[bool]
 }
 
 type Question struct {
-	Code []int
-	Card *verifiers.VerifierCard
+	Code     []int
+	Card     *verifiers.VerifierCard
+	Verifier int
+}
+
+// CodesTested returns the number of rounds the player has asked a question in, each tests one code
+func (p *PlayerMoves) CodesTested() int {
+	return p.codesTested
+}
+
+// QuestionsAsked returns the number of verifiers the player has tested
+func (p *PlayerMoves) QuestionsAsked() int {
+	return len(p.questionsAsked)
+}
+
+// GuessedCorrectly returns whether the player has guessed the code, and if so whether the guess was correct
+func (p *PlayerMoves) GuessedCorrectly() (correct bool, guessed bool) {
+	return p.guessedCorrectly.Value(), p.guessedCorrectly.HasValue()
+}
+
+func (p *PlayerMoves) startedRound(code []int) error {
+	if p.guessedCorrectly.HasValue() {
+		return fmt.Errorf("%w. Player: %v Code: %v", ErrAlreadyGuessed, p.player.GetPlayerName(), code)
+	} else if p.roundCode != nil {
+		return fmt.Errorf("%w: round testing code %v must end before testing %v", ErrRuleViolation, p.roundCode, code)
+	}
+
+	p.roundCode = slices.Clone(code)
+	p.questionsAskedThisRound = 0
+	return nil
 }
 
-func (p *PlayerMoves) askedQuestion(code []int, card *verifiers.VerifierCard) error {
+func (p *PlayerMoves) askedQuestion(code []int, verifier int, card *verifiers.VerifierCard, rules Rules) error {
 	if p.guessedCorrectly.HasValue() {
-		return fmt.Errorf("illegal move player has already guessed. Player: ", p.player.GetPlayerName(), " Code: ", code, " Card: ", card)
+		return fmt.Errorf("%w. Player: %v Code: %v Card: %v", ErrAlreadyGuessed, p.player.GetPlayerName(), code, card)
+	} else if p.roundCode == nil {
+		return fmt.Errorf("%w: a round must start before testing code %v", ErrRuleViolation, code)
+	} else if !slices.Equal(p.roundCode, code) {
+		return fmt.Errorf("%w: round is testing code %v, not %v", ErrRuleViolation, p.roundCode, code)
+	} else if rules.QuestionsPerRound > 0 && p.questionsAskedThisRound >= rules.QuestionsPerRound {
+		return fmt.Errorf("%w: only %v verifiers may be tested each round", ErrRuleViolation, rules.QuestionsPerRound)
 	}
 
-	var lastCode []int
-	if len(p.questionsAsked) > 0 {
-		lastCode = p.questionsAsked[len(p.questionsAsked)-1].Code
+	for _, question := range p.questionsAsked[len(p.questionsAsked)-p.questionsAskedThisRound:] {
+		if question.Verifier == verifier {
+			return fmt.Errorf("%w: verifier %v was already tested this round", ErrRuleViolation, verifier+1)
+		}
 	}
 
-	if !slices.Equal(lastCode, code) || p.questionsAskedThisCode == questionsPerCode {
+	// A round only tests its code once a verifier is tested against it
+	if p.questionsAskedThisRound == 0 {
 		p.codesTested += 1
-		p.questionsAskedThisCode = 0
 	}
+	p.questionsAsked = append(p.questionsAsked, Question{Code: code, Card: card, Verifier: verifier})
+	p.questionsAskedThisRound += 1
 
-	p.questionsAsked = append(p.questionsAsked, Question{Code: code, Card: card})
-	p.questionsAskedThisCode += 1
+	return nil
+}
+
+func (p *PlayerMoves) endedRound() error {
+	if p.roundCode == nil {
+		return fmt.Errorf("%w: no round to end", ErrRuleViolation)
+	}
 
+	p.roundCode = nil
 	return nil
 }
 
-func (p *PlayerMoves) madeGuess(code []int, correct bool) error {
+func (p *PlayerMoves) madeGuess(code []int, correct bool, rules Rules) error {
 	if p.guessedCorrectly.HasValue() {
-		return fmt.Errorf("Illegal move player has already guessed. Player: ", p.player.GetPlayerName(), " Code: ", code)
+		return fmt.Errorf("%w. Player: %v Code: %v", ErrAlreadyGuessed, p.player.GetPlayerName(), code)
+	} else if p.roundCode != nil && !rules.GuessMidRound {
+		return fmt.Errorf("%w: round testing code %v must end before guessing", ErrRuleViolation, p.roundCode)
 	}
 
 	p.codeGuessed = code
@@ -0,0 +1,172 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/caseymerrill/turingsolver/types"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// PuzzleVersion is the version of the puzzle file format written by SavePuzzles, LoadPuzzles rejects other versions
+const PuzzleVersion = 1
+
+// Puzzle is an AutoGame as it is saved or sent to a server, with the secret verifier of each card as its index on the
+// card so that XTREAM cards, which are combined again when loaded, keep their secret
+type Puzzle = types.Puzzle
+
+type puzzleFile struct {
+	Version int      `json:"version"`
+	Puzzles []Puzzle `json:"puzzles"`
+}
+
+// NewPuzzle returns the puzzle of a game, to be saved
+func NewPuzzle(autoGame *AutoGame) Puzzle {
+	solution := autoGame.Solution()
+	rules := autoGame.Rules()
+	puzzle := Puzzle{Code: solution.Code, Rules: &rules}
+	for cardIndex, card := range autoGame.GetVerifierCards() {
+		puzzle.Cards = append(puzzle.Cards, card.CardNumber)
+		for verifierIndex, verifier := range card.Verifiers {
+			if verifier == solution.Verifiers[cardIndex] {
+				puzzle.Verifiers = append(puzzle.Verifiers, verifierIndex)
+			}
+		}
+	}
+
+	return puzzle
+}
+
+// PuzzleGame returns a new game of the puzzle that nobody has played
+func PuzzleGame(p Puzzle) (*AutoGame, error) {
+	if len(p.Verifiers) != len(p.Cards) {
+		return nil, fmt.Errorf("puzzle has %v cards but %v secret verifiers", len(p.Cards), len(p.Verifiers))
+	} else if err := CheckCode(p.Code); err != nil {
+		return nil, fmt.Errorf("puzzle secret : %w", err)
+	}
+
+	cards := make([]*verifiers.VerifierCard, len(p.Cards))
+	secretVerifiers := make([]*verifiers.Verifier, len(p.Cards))
+	for i, cardNumber := range p.Cards {
+		card, err := verifiers.CardFromNumber(cardNumber)
+		if err != nil {
+			return nil, err
+		} else if p.Verifiers[i] < 0 || p.Verifiers[i] >= len(card.Verifiers) {
+			return nil, fmt.Errorf("card %v has no verifier %v", cardNumber, p.Verifiers[i])
+		}
+
+		cards[i] = card
+		secretVerifiers[i] = card.Verifiers[p.Verifiers[i]]
+	}
+
+	if err := checkSecret(secretVerifiers, p.Code); err != nil {
+		return nil, fmt.Errorf("puzzle secret : %w", err)
+	}
+
+	puzzleGame := NewAutoGame(cards, secretVerifiers, p.Code)
+	if p.Rules != nil {
+		puzzleGame.SetRules(*p.Rules)
+	}
+	return puzzleGame, nil
+}
+
+// checkSecret returns an error unless the secret is a solution the way solvers find them: the code passes every secret
+// verifier, no other code passes them all, and every verifier is needed to rule the other codes out
+func checkSecret(secretVerifiers []*verifiers.Verifier, code []int) error {
+	for i, verifier := range secretVerifiers {
+		if !verifier.Verify(code...) {
+			return fmt.Errorf("code %v fails verifier %v", CodeString(code), i+1)
+		}
+	}
+
+	if passing := codesPassing(secretVerifiers, -1); len(passing) != 1 {
+		return fmt.Errorf("%v codes pass every verifier, not only %v", len(passing), CodeString(code))
+	}
+
+	for i := range secretVerifiers {
+		if len(codesPassing(secretVerifiers, i)) == 1 {
+			return fmt.Errorf("verifier %v isn't needed to find the code", i+1)
+		}
+	}
+
+	return nil
+}
+
+// codesPassing returns the codes that pass every verifier but the one at without, -1 to use them all
+func codesPassing(secretVerifiers []*verifiers.Verifier, without int) [][]int {
+	var passing [][]int
+	for first := 1; first <= 5; first++ {
+		for second := 1; second <= 5; second++ {
+			for third := 1; third <= 5; third++ {
+				code := []int{first, second, third}
+				passes := true
+				for i, verifier := range secretVerifiers {
+					passes = passes && (i == without || verifier.Verify(code...))
+				}
+				if passes {
+					passing = append(passing, code)
+				}
+			}
+		}
+	}
+
+	return passing
+}
+
+// SavePuzzles writes the puzzles of games to filename, every game must be an *AutoGame
+func SavePuzzles(filename string, games []Game) error {
+	file := puzzleFile{Version: PuzzleVersion, Puzzles: make([]Puzzle, len(games))}
+	for i, gameToSave := range games {
+		autoGame, ok := gameToSave.(*AutoGame)
+		if !ok {
+			return fmt.Errorf("game %v has no secret to save", i+1)
+		}
+		file.Puzzles[i] = NewPuzzle(autoGame)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	} else if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("saving puzzles : %w", err)
+	}
+
+	return nil
+}
+
+// LoadPuzzles returns new games of the puzzles saved to filename, in the order they were saved
+func LoadPuzzles(filename string) ([]Game, error) {
+	puzzles, err := ReadPuzzles(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]Game, len(puzzles))
+	for i, puzzle := range puzzles {
+		puzzleGame, err := PuzzleGame(puzzle)
+		if err != nil {
+			return nil, fmt.Errorf("puzzle %v of %v : %w", i+1, filename, err)
+		}
+		games[i] = puzzleGame
+	}
+
+	return games, nil
+}
+
+// ReadPuzzles returns the puzzles saved to filename, in the order they were saved
+func ReadPuzzles(filename string) ([]Puzzle, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading puzzles : %w", err)
+	}
+
+	file := puzzleFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing puzzles %v : %w", filename, err)
+	} else if file.Version != PuzzleVersion {
+		return nil, fmt.Errorf("puzzles %v have version %v, only version %v can be loaded", filename, file.Version, PuzzleVersion)
+	}
+
+	return file.Puzzles, nil
+}
@@ -0,0 +1,177 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogVersion is the version of the log format written by a Recorder, readers reject other versions
+const LogVersion = 1
+
+// Kinds of LogEntry
+const (
+	LogGame       = "game"
+	LogStartRound = "startRound"
+	LogQuestion   = "question"
+	LogEndRound   = "endRound"
+	LogGuess      = "guess"
+)
+
+// LogEntry is a line of a game log. The first entry of each game is a LogGame entry with the puzzle, every move made
+// in the game follows it along with the answer.
+type LogEntry struct {
+	Version int       `json:"version"`
+	Kind    string    `json:"kind"`
+	Time    time.Time `json:"time"`
+	Game    int       `json:"game"`
+
+	// Cards, Rules and the secret Code and verifier indexes of each card describe the puzzle of LogGame entries. The
+	// secret is left out for games that don't know it.
+	Cards     []int  `json:"cards,omitempty"`
+	Rules     *Rules `json:"rules,omitempty"`
+	Verifiers []int  `json:"verifiers,omitempty"`
+
+	// Player, PlayerID and Round are set for moves. Players are numbered from 1 in the order they first move, as names
+	// can be shared, and rounds are numbered from 1 for each player.
+	Player   string `json:"player,omitempty"`
+	PlayerID int    `json:"playerId,omitempty"`
+	Round    int    `json:"round,omitempty"`
+
+	Code     []int  `json:"code,omitempty"`
+	Verifier int    `json:"verifier,omitempty"`
+	Result   bool   `json:"result,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Recorder writes the moves of every game it records to one log, numbering the games in the order they are recorded
+type Recorder struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+	games   int
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// Record returns the game wrapped so that every move made in it is logged. The puzzle is logged now, with the secret
+// if the game is an *AutoGame.
+func (r *Recorder) Record(recorded Game) Game {
+	r.lock.Lock()
+	r.games += 1
+	gameID := r.games
+	r.lock.Unlock()
+
+	rules := recorded.Rules()
+	header := LogEntry{Kind: LogGame, Game: gameID, Rules: &rules}
+	for _, card := range recorded.GetVerifierCards() {
+		header.Cards = append(header.Cards, card.CardNumber)
+	}
+	if autoGame, ok := recorded.(*AutoGame); ok {
+		puzzle := NewPuzzle(autoGame)
+		header.Code, header.Verifiers = puzzle.Code, puzzle.Verifiers
+	}
+	r.write(header)
+
+	return &RecordingGame{
+		Game:      recorded,
+		recorder:  r,
+		id:        gameID,
+		playerIDs: make(map[Player]int),
+		rounds:    make(map[Player]int),
+	}
+}
+
+func (r *Recorder) write(entry LogEntry) {
+	entry.Version = LogVersion
+	entry.Time = time.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.encoder.Encode(entry); err != nil {
+		fmt.Println("Writing game log :", err)
+	}
+}
+
+// RecordingGame is a game that logs every move made in it, made by Recorder.Record
+type RecordingGame struct {
+	Game
+	recorder *Recorder
+	id       int
+
+	playersLock sync.Mutex
+	playerIDs   map[Player]int
+	rounds      map[Player]int
+}
+
+func (g *RecordingGame) StartRound(player Player, code []int) error {
+	// Rounds the game rejects aren't counted, they are logged with the round before them
+	err := g.Game.StartRound(player, code)
+	if err == nil {
+		g.playersLock.Lock()
+		g.rounds[player] += 1
+		g.playersLock.Unlock()
+	}
+	g.log(player, LogEntry{Kind: LogStartRound, Code: code}, err)
+	return err
+}
+
+func (g *RecordingGame) AskQuestion(player Player, code []int, verifier int) (bool, error) {
+	result, err := g.Game.AskQuestion(player, code, verifier)
+	g.log(player, LogEntry{Kind: LogQuestion, Code: code, Verifier: verifier, Result: result}, err)
+	return result, err
+}
+
+func (g *RecordingGame) EndRound(player Player) error {
+	err := g.Game.EndRound(player)
+	g.log(player, LogEntry{Kind: LogEndRound}, err)
+	return err
+}
+
+func (g *RecordingGame) MakeGuess(player Player, code []int) (bool, error) {
+	correct, err := g.Game.MakeGuess(player, code)
+	g.log(player, LogEntry{Kind: LogGuess, Code: code, Result: correct}, err)
+	return correct, err
+}
+
+func (g *RecordingGame) log(player Player, entry LogEntry, err error) {
+	g.playersLock.Lock()
+	if _, ok := g.playerIDs[player]; !ok {
+		g.playerIDs[player] = len(g.playerIDs) + 1
+	}
+	entry.PlayerID = g.playerIDs[player]
+	entry.Round = g.rounds[player]
+	g.playersLock.Unlock()
+
+	entry.Game = g.id
+	entry.Player = player.GetPlayerName()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	g.recorder.write(entry)
+}
+
+// ReadLog reads every entry of a game log written by a Recorder
+func ReadLog(r io.Reader) ([]LogEntry, error) {
+	var entries []LogEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := LogEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("reading line %v : %w", line, err)
+		} else if entry.Version != LogVersion {
+			return nil, fmt.Errorf("line %v has log version %v, only version %v can be read", line, entry.Version, LogVersion)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading log : %w", err)
+	}
+
+	return entries, nil
+}
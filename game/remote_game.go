@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 
@@ -14,91 +15,211 @@ import (
 type RemoteGame struct {
 	addr          string
 	client        *http.Client
+	token         string
 	gameIndex     int
 	verifierCards []*verifiers.VerifierCard
+	rules         Rules
+
+	// answers and guessed are what the player did in the game before joining, when it rejoined
+	answers []Answer
+	guessed bool
 }
 
+// JoinGames joins the games of the server's default lobby
 func JoinGames(addr string, playerName string) ([]Game, error) {
+	return JoinLobby(addr, "", playerName)
+}
+
+// JoinLobby joins the games of a lobby of the server, the default lobby when lobby is empty
+func JoinLobby(addr string, lobby string, playerName string) ([]Game, error) {
+	games, _, err := Join(addr, types.JoinRequest{PlayerName: playerName, Lobby: lobby})
+	return games, err
+}
+
+// Join joins the games of a lobby of the server and returns the token the player can rejoin with. Rejoining with the
+// token, or the key registered for the player's name, continues the player's games.
+func Join(addr string, request types.JoinRequest) ([]Game, string, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
-		return nil, fmt.Errorf("initializing cookiejar : %w", err)
+		return nil, "", fmt.Errorf("initializing cookiejar : %w", err)
 	}
 
 	client := &http.Client{
 		Jar: jar,
 	}
 
-	if err := join(addr, client, playerName); err != nil {
-		return nil, fmt.Errorf("joining game : %w", err)
+	joined, err := join(addr, client, request)
+	if err != nil {
+		return nil, "", fmt.Errorf("joining game : %w", err)
 	}
 
-	games, err := getGames(addr, client)
+	games, err := getGames(addr, client, joined.Token)
 	if err != nil {
-		return nil, fmt.Errorf("getting games : %w", err)
+		return nil, "", fmt.Errorf("getting games : %w", err)
 	}
 
-	remoteGames := make([]Game, len(games))
-	for i, game := range games {
+	remoteGames := make([]Game, len(games.Games))
+	for i, game := range games.Games {
+		gameRules := DefaultRules
+		if i < len(games.Rules) {
+			gameRules = games.Rules[i]
+		}
+
 		cards := make([]*verifiers.VerifierCard, len(game))
 		for j, cardNumber := range game {
-			if cardNumber < 1 || cardNumber > len(verifiers.Cards) {
-				return nil, fmt.Errorf("invalid card number: %v", cardNumber)
+			// Lobbies can be uploaded XTREAM games
+			card, err := verifiers.CardFromNumber(cardNumber)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid card number: %w", err)
 			}
-
-			cards[j] = &verifiers.Cards[cardNumber-1]
+			cards[j] = card
 		}
 
-		remoteGames[i] = &RemoteGame{
+		remoteGame := &RemoteGame{
 			addr:          addr,
 			client:        client,
+			token:         joined.Token,
 			gameIndex:     i,
 			verifierCards: cards,
+			rules:         gameRules,
+		}
+		if i < len(games.Answers) {
+			for _, answer := range games.Answers[i] {
+				remoteGame.answers = append(remoteGame.answers, Answer{Code: answer.Code, Verifier: answer.VerifierIndex, Valid: answer.Result})
+			}
 		}
+		remoteGame.guessed = i < len(games.Guessed) && games.Guessed[i]
+		remoteGames[i] = remoteGame
 	}
 
-	return remoteGames, nil
+	return remoteGames, joined.Token, nil
 }
 
-func join(addr string, client *http.Client, playerName string) error {
-	request := types.JoinRequest{PlayerName: playerName}
+func join(addr string, client *http.Client, request types.JoinRequest) (types.JoinResponse, error) {
+	joined := types.JoinResponse{}
 	requestBytes, err := json.Marshal(request)
 	if err != nil {
-		return err
+		return joined, err
 	}
 
 	response, err := client.Post(addr+"/join", "application/json", bytes.NewBuffer(requestBytes))
 	if err != nil {
-		return fmt.Errorf("joining game : %w", err)
-	} else if err := checkResponse(response); err != nil {
-		return err
+		return joined, fmt.Errorf("joining game : %w", err)
 	}
+	defer response.Body.Close()
 
-	return nil
+	if err := checkResponse(response); err != nil {
+		return joined, err
+	} else if err := json.NewDecoder(response.Body).Decode(&joined); err != nil {
+		return joined, &TransportError{Op: "decoding response", Err: err}
+	}
+
+	return joined, nil
+}
+
+// CreateLobby creates a lobby of the server with its own games, adminKey is the server's admin key
+func CreateLobby(addr string, adminKey string, request types.CreateLobbyRequest) (types.LobbyResponse, error) {
+	lobby := types.LobbyResponse{}
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return lobby, err
+	}
+
+	httpRequest, err := newRequest("POST", addr+"/lobbies", adminKey, bytes.NewBuffer(requestBytes))
+	if err != nil {
+		return lobby, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(httpRequest)
+	if err != nil {
+		return lobby, &TransportError{Op: "creating lobby", Err: err}
+	}
+	defer response.Body.Close()
+
+	if err := checkResponse(response); err != nil {
+		return lobby, err
+	} else if err := json.NewDecoder(response.Body).Decode(&lobby); err != nil {
+		return lobby, &TransportError{Op: "decoding response", Err: err}
+	}
+
+	return lobby, nil
 }
 
-func getGames(addr string, client *http.Client) ([][]int, error) {
-	response, err := client.Get(addr + "/player/games")
+// ListLobbies returns every lobby of the server
+func ListLobbies(addr string) ([]types.LobbyResponse, error) {
+	response, err := http.Get(addr + "/lobbies")
 	if err != nil {
-		return nil, fmt.Errorf("getting games : %w", err)
-	} else if err := checkResponse(response); err != nil {
+		return nil, &TransportError{Op: "listing lobbies", Err: err}
+	}
+	defer response.Body.Close()
+
+	if err := checkResponse(response); err != nil {
 		return nil, err
 	}
 
+	responseBody := types.ListLobbiesResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
+		return nil, &TransportError{Op: "decoding response", Err: err}
+	}
+
+	return responseBody.Lobbies, nil
+}
+
+// getGames returns the card numbers and rules of every game, servers that don't send rules play by DefaultRules
+func getGames(addr string, client *http.Client, token string) (types.GetGamesResponse, error) {
 	responseBody := types.GetGamesResponse{}
+	request, err := newRequest("GET", addr+"/player/games", token, nil)
+	if err != nil {
+		return responseBody, err
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return responseBody, fmt.Errorf("getting games : %w", err)
+	}
+	defer response.Body.Close()
+
+	if err := checkResponse(response); err != nil {
+		return responseBody, err
+	}
+
 	responseDecoder := json.NewDecoder(response.Body)
 	if err := responseDecoder.Decode(&responseBody); err != nil {
-		return nil, fmt.Errorf("decoding response : %w", err)
+		return responseBody, fmt.Errorf("decoding response : %w", err)
+	}
+
+	return responseBody, nil
+}
+
+// newRequest returns a request to the server authenticated with the player's token, servers that don't give tokens
+// authenticate players with the session cookie instead
+func newRequest(method string, url string, token string, body io.Reader) (*http.Request, error) {
+	request, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
 	}
 
-	return responseBody.Games, nil
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return request, nil
 }
 
+// checkResponse returns the error a server sent, a *TransportError unless the server named a move that isn't allowed
 func checkResponse(response *http.Response) error {
-	if response.StatusCode != 200 {
-		return fmt.Errorf("response status code: %v", response.StatusCode)
+	if response.StatusCode == 200 {
+		return nil
 	}
 
-	return nil
+	errorResponse := types.ErrorResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&errorResponse); err == nil {
+		if err := errorFromCode(errorResponse.Code, errorResponse.Error); err != nil {
+			return err
+		}
+	}
+
+	return &TransportError{Op: "reading response", Err: fmt.Errorf("response status code: %v %v", response.StatusCode, errorResponse.Error)}
 }
 
 func (g *RemoteGame) String() string {
@@ -109,66 +230,88 @@ func (g *RemoteGame) GetVerifierCards() []*verifiers.VerifierCard {
 	return g.verifierCards
 }
 
-func (g *RemoteGame) AskQuestion(player Player, code []int, verifier int) bool {
-	request := types.AskQuestionRequest{
-		GameIndex:     g.gameIndex,
-		VerifierIndex: verifier,
-		Code:          code,
-	}
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		fmt.Println("Error marshalling request while testing verifier:", err)
-		return false
+func (g *RemoteGame) Rules() Rules {
+	return g.rules
+}
+
+// Answers returns the answers the player got in the game before it rejoined
+func (g *RemoteGame) Answers() []Answer {
+	return g.answers
+}
+
+// Guessed returns true if the player guessed the game before it rejoined
+func (g *RemoteGame) Guessed() bool {
+	return g.guessed
+}
+
+func (g *RemoteGame) StartRound(player Player, code []int) error {
+	request := types.StartRoundRequest{
+		GameIndex: g.gameIndex,
+		Code:      code,
 	}
 
-	response, err := g.client.Post(g.addr+"/player/test-verifier", "application/json", bytes.NewBuffer(requestBytes))
-	if err != nil {
-		fmt.Println("error testing verifier:", err)
-		return false
-	} else if err := checkResponse(response); err != nil {
-		fmt.Println("Response error testing verifier:", err)
-		return false
+	_, err := g.post("/player/start-round", request)
+	return err
+}
+
+func (g *RemoteGame) EndRound(player Player) error {
+	request := types.EndRoundRequest{
+		GameIndex: g.gameIndex,
 	}
 
-	responseBody := types.BinaryResponse{}
-	responseDecoder := json.NewDecoder(response.Body)
-	if err := responseDecoder.Decode(&responseBody); err != nil {
-		fmt.Println("Error decoding response while testing verifier:", err)
-		return false
+	_, err := g.post("/player/end-round", request)
+	return err
+}
+
+func (g *RemoteGame) AskQuestion(player Player, code []int, verifier int) (bool, error) {
+	request := types.AskQuestionRequest{
+		GameIndex:     g.gameIndex,
+		VerifierIndex: verifier,
+		Code:          code,
 	}
 
-	return responseBody.Result
+	return g.post("/player/test-verifier", request)
 }
 
-func (g *RemoteGame) MakeGuess(player Player, code []int) bool {
+func (g *RemoteGame) MakeGuess(player Player, code []int) (bool, error) {
 	request := types.MakeGuessRequest{
 		GameIndex: g.gameIndex,
 		Code:      code,
 	}
 
+	return g.post("/player/make-guess", request)
+}
+
+// post sends the request to the server and returns the result of its binary response
+func (g *RemoteGame) post(path string, request any) (bool, error) {
 	requestBytes, err := json.Marshal(request)
 	if err != nil {
-		fmt.Println("Error marshalling request while making guess:", err)
-		return false
+		return false, fmt.Errorf("marshalling request : %w", err)
 	}
 
-	response, err := g.client.Post(g.addr+"/player/make-guess", "application/json", bytes.NewBuffer(requestBytes))
+	httpRequest, err := newRequest("POST", g.addr+path, g.token, bytes.NewBuffer(requestBytes))
 	if err != nil {
-		fmt.Println("error making guess:", err)
-		return false
-	} else if err := checkResponse(response); err != nil {
-		fmt.Println("Response error making guess:", err)
-		return false
+		return false, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	response, err := g.client.Do(httpRequest)
+	if err != nil {
+		return false, &TransportError{Op: "posting " + path, Err: err}
+	}
+	defer response.Body.Close()
+
+	if err := checkResponse(response); err != nil {
+		return false, err
 	}
 
 	responseBody := types.BinaryResponse{}
 	responseDecoder := json.NewDecoder(response.Body)
 	if err := responseDecoder.Decode(&responseBody); err != nil {
-		fmt.Println("Error decoding response while making guess:", err)
-		return false
+		return false, &TransportError{Op: "decoding response", Err: err}
 	}
 
-	return responseBody.Result
+	return responseBody.Result, nil
 }
 
 func (g *RemoteGame) Rank() [][]Player {
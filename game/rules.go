@@ -0,0 +1,9 @@
+package game
+
+import "github.com/caseymerrill/turingsolver/types"
+
+// Rules are the limits a game puts on the moves of every player, shared with remote games
+type Rules = types.Rules
+
+// DefaultRules are the rules of the board game
+var DefaultRules = Rules{QuestionsPerRound: 3}
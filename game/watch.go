@@ -0,0 +1,106 @@
+package game
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/caseymerrill/turingsolver/types"
+)
+
+// WatchLobby calls handle with each event of a lobby of the server, the default lobby when lobby is empty, until ctx is
+// done or the server closes the stream. Watching with the token of a player of the lobby shows the player's own moves and
+// the games it has guessed, spectators watch with an empty token.
+func WatchLobby(ctx context.Context, addr string, lobby string, token string, filter types.WatchFilter, handle func(types.GameEvent)) error {
+	if lobby == "" {
+		lobby = types.DefaultLobby
+	}
+
+	query := url.Values{}
+	if filter.GameIndex != -1 {
+		query.Set("game", strconv.Itoa(filter.GameIndex))
+	}
+	if filter.Player != "" {
+		query.Set("player", filter.Player)
+	}
+
+	request, err := newRequest("GET", addr+"/lobbies/"+url.PathEscape(lobby)+"/events?"+query.Encode(), token, nil)
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return &TransportError{Op: "watching lobby", Err: err}
+	}
+	defer response.Body.Close()
+
+	if err := checkResponse(response); err != nil {
+		return err
+	}
+
+	// Each event is sent as an event line, a data line of JSON and a blank line
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		event := types.GameEvent{}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return &TransportError{Op: "decoding event", Err: err}
+		}
+		handle(event)
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return &TransportError{Op: "reading events", Err: err}
+	}
+
+	return nil
+}
+
+// FormatEvent describes an event of a lobby in a line, games are numbered from 1
+func FormatEvent(event types.GameEvent) string {
+	game := fmt.Sprintf("Game %v: ", event.GameIndex+1)
+	if event.Revealed {
+		game += "revealed "
+	}
+	switch event.Kind {
+	case types.EventPlayerJoined:
+		return event.Player + " joined"
+	case types.EventQuestionAsked:
+		outcome := "(hidden)"
+		if event.Result != nil && *event.Result {
+			outcome = "passes"
+		} else if event.Result != nil {
+			outcome = "fails"
+		}
+		return fmt.Sprintf("%v%v tested %v against verifier %v: %v", game, event.Player, CodeString(event.Code), event.VerifierIndex+1, outcome)
+	case types.EventGuessMade:
+		if event.Result == nil {
+			return fmt.Sprintf("%v%v guessed (hidden)", game, event.Player)
+		}
+
+		outcome := "wrong"
+		if *event.Result {
+			outcome = "correct"
+		}
+		return fmt.Sprintf("%v%v guessed %v: %v", game, event.Player, CodeString(event.Code), outcome)
+	case types.EventRankChanged:
+		places := make([]string, len(event.Rankings))
+		for i, tied := range event.Rankings {
+			places[i] = fmt.Sprintf("%v. %v", i+1, strings.Join(tied, ", "))
+		}
+		return game + "ranking " + strings.Join(places, "  ")
+	}
+
+	return game + event.Kind
+}
@@ -2,6 +2,7 @@ package game_generator
 
 import (
 	"math/rand"
+	"sync"
 	"sync/atomic"
 
 	"github.com/caseymerrill/turingsolver/game"
@@ -15,7 +16,6 @@ var GamesGenrated atomic.Int32
 var GamesThrownAway atomic.Int32
 
 func GenerateGame(numberOfVerifierCards int, minSolutions int) game.Game {
-	solutionFinder := solver.Solver{}
 	for {
 		cards := make([]*verifiers.VerifierCard, 0, numberOfVerifierCards)
 		usedCards := set.Make[int]()
@@ -31,7 +31,7 @@ func GenerateGame(numberOfVerifierCards int, minSolutions int) game.Game {
 
 		// Interactive game used here because it doesn't require solution/code
 		possibleGame := game.NewInteractiveGame(cards)
-		solutions := solutionFinder.InitialSolutions(possibleGame)
+		solutions := solver.InitialSolutions(possibleGame)
 		if len(solutions) >= minSolutions {
 			TotalPotentialSolutions.Add(int32(len(solutions)))
 			GamesGenrated.Add(1)
@@ -42,3 +42,32 @@ func GenerateGame(numberOfVerifierCards int, minSolutions int) game.Game {
 		}
 	}
 }
+
+// GenerateGames generates games in parallel, every game with the rules given
+func GenerateGames(numberOfGamesToGenerate, nVerifiers, minSolutions int, rules game.Rules) []game.Game {
+	games := make(chan game.Game, numberOfGamesToGenerate/10+1)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < numberOfGamesToGenerate; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			generated := GenerateGame(nVerifiers, minSolutions)
+			generated.(*game.AutoGame).SetRules(rules)
+			games <- generated
+		}()
+
+	}
+
+	go func() {
+		wg.Wait()
+		close(games)
+	}()
+
+	result := make([]game.Game, 0, numberOfGamesToGenerate)
+	for g := range games {
+		result = append(result, g)
+	}
+
+	return result
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/solver"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// printHint prints what to ask next in a game played at the table, given the cards and the answers so far
+func printHint(cardNumbers string, answersFile string, answerTexts []string, hintSolver *solver.Solver) error {
+	cards, err := parseCards(cardNumbers)
+	if err != nil {
+		return err
+	}
+
+	if answersFile != "" {
+		fileAnswers, err := readAnswers(answersFile)
+		if err != nil {
+			return err
+		}
+		answerTexts = append(fileAnswers, answerTexts...)
+	}
+
+	session := hintSolver.NewSession(game.NewInteractiveGame(cards))
+	for _, answerText := range answerTexts {
+		answer, err := game.ParseAnswer(answerText, len(cards))
+		if err != nil {
+			return err
+		} else if err := session.Apply(answer); err != nil {
+			return fmt.Errorf("applying %v : %w", answer, err)
+		}
+	}
+
+	fmt.Printf("Found %v solutions:\n", len(session.Solutions()))
+	for _, solution := range session.Solutions() {
+		fmt.Printf("  %v\n", solution)
+	}
+	fmt.Println()
+
+	hint, err := session.Recommend()
+	if err != nil {
+		return err
+	}
+
+	printHintTree(hint, "")
+	return nil
+}
+
+// parseCards reads card numbers separated by commas or spaces
+func parseCards(cardNumbers string) ([]*verifiers.VerifierCard, error) {
+	var cards []*verifiers.VerifierCard
+	for _, field := range strings.FieldsFunc(cardNumbers, func(r rune) bool { return r == ',' || r == ' ' }) {
+		cardNumber, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("could not recognize %q as a card number", field)
+		}
+
+		card, err := verifiers.CardFromNumber(cardNumber)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+
+	return cards, nil
+}
+
+// readAnswers reads one answer per line, blank lines and lines starting with # are skipped
+func readAnswers(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening answers : %w", err)
+	}
+	defer file.Close()
+
+	var answers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			answers = append(answers, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading answers : %w", err)
+	}
+
+	return answers, nil
+}
+
+func printHintTree(hint *solver.Hint, indent string) {
+	if hint.Solved && hint.Unconfirmed {
+		fmt.Printf("%vThe code is probably %v, ask for another hint to check the answers\n", indent, game.CodeString(hint.Code))
+		return
+	} else if hint.Solved {
+		fmt.Printf("%vGuess code %v\n", indent, game.CodeString(hint.Code))
+		return
+	}
+
+	again := ""
+	if hint.Reask {
+		again = " again, the answer may have been wrong"
+	}
+	fmt.Printf("%vTest code %v against verifier %v%v\n", indent, game.CodeString(hint.Code), hint.Verifier+1, again)
+
+	for _, followUp := range []struct {
+		answer string
+		hint   *solver.Hint
+	}{{"yes", hint.IfTrue}, {"no", hint.IfFalse}} {
+		if followUp.hint == nil {
+			fmt.Printf("%v  If %v, the round is over, ask for another hint\n", indent, followUp.answer)
+		} else {
+			fmt.Printf("%v  If %v:\n", indent, followUp.answer)
+			printHintTree(followUp.hint, indent+"    ")
+		}
+	}
+}
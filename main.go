@@ -1,20 +1,26 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"runtime/pprof"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/caseymerrill/turingsolver/server"
 
 	"github.com/caseymerrill/turingsolver/game"
 	"github.com/caseymerrill/turingsolver/game_generator"
 	"github.com/caseymerrill/turingsolver/solver"
+	"github.com/caseymerrill/turingsolver/tui"
+	"github.com/caseymerrill/turingsolver/types"
 	"github.com/caseymerrill/turingsolver/verifiers"
 	"github.com/docopt/docopt-go"
 )
@@ -22,20 +28,72 @@ import (
 const docString = `TuringSolver
 
 Usage:
-  turingsolver --interactive [--solver=<solver>]
-  turingsolver --server --gen=<number-of-games> [--n-cards=<number-of-cards> --min-solutions=<min-solutions>]
-  turingsolver --gen=<number-of-games> [--n-cards=<number-of-cards> --min-solutions=<min-solutions> --profile] [--solver=<solvers>...]
-  turingsolver --remote=<url> [--solver=<solvers>...]
+  turingsolver --interactive [--tui] [--solver=<solver> --events=<file> --explain --save=<file>]
+  turingsolver --resume=<file> [--tui] [--solver=<solver> --events=<file> --explain]
+  turingsolver --server (--gen=<number-of-games> | --puzzles=<file>) [--save-puzzles=<file> --n-cards=<number-of-cards> --min-solutions=<min-solutions> --questions-per-round=<n> --guess-mid-round --record=<file> --state=<file> --admin-key=<key> --config=<file> --address=<host> --port=<port> --tls-cert=<file> --tls-key=<file> --session-secret=<secret> --secure-cookies --cookie-same-site=<mode>]
+  turingsolver --server --state=<file> [--record=<file> --admin-key=<key> --config=<file> --address=<host> --port=<port> --tls-cert=<file> --tls-key=<file> --session-secret=<secret> --secure-cookies --cookie-same-site=<mode>]
+  turingsolver (--gen=<number-of-games> | --puzzles=<file>) [--save-puzzles=<file> --n-cards=<number-of-cards> --min-solutions=<min-solutions> --questions-per-round=<n> --guess-mid-round --profile --optimal --events=<file> --summary --lie=<probability> --explain --record=<file>] [--solver=<solvers>...]
+  turingsolver --replay=<file> [--game=<n>]
+  turingsolver --remote=<url> [--lobby=<name> --key=<key> --events=<file> --summary] [--solver=<solvers>...]
+  turingsolver --remote=<url> --create-lobby=<name> --admin-key=<key> (--gen=<number-of-games> | --puzzles=<file>) [--n-cards=<number-of-cards> --min-solutions=<min-solutions> --questions-per-round=<n> --guess-mid-round]
+  turingsolver --remote=<url> --list-lobbies
+  turingsolver --remote=<url> --watch [--lobby=<name> --key=<key> --game=<n> --player=<name>]
+  turingsolver --hint --cards=<cards> [--answers=<file>] [--answer=<answer>...] [--solver=<solver>]
+  turingsolver --practice [--n-cards=<number-of-cards> --min-solutions=<min-solutions> --questions-per-round=<n> --guess-mid-round] [--solver=<solver>]
   turingsolver --print-cards
-  
+  turingsolver --list-solvers
+
  Options:
  -h --help                       Show this screen.
  --print-cards                   Print the available verifier cards.
+ --list-solvers                  Print the available solvers and strategies.
  --interactive                   Run the game in interactive mode.
+--tui                            Play interactive games on a full screen board, answering with key presses.
+--save=<file>                    Save the interactive game to <file> after every answer, to continue it with --resume.
+--resume=<file>                  Continue an interactive game saved to <file>, saving it back to <file>.
+ --hint                          Recommend the next question of a game played at the table.
+--practice                       Play a generated game yourself, then see how the solver plays it.
+--cards=<cards>                  Verifier card numbers of the game, e.g. 4,9,11,14.
+--answers=<file>                 File of answers so far, one per line, e.g. 245 2 y for code 245 passing verifier 2.
+--answer=<answer>                An answer so far, e.g. "245 2 y".
+--state=<file>                   Keep the server's lobbies, players and moves in <file>, a server restarted with the same
+                                 file continues where it stopped.
+--config=<file>                  Read the server's listener settings from a JSON file, the flags below override it.
+--address=<host>                 Serve on <host> only instead of every interface.
+--port=<port>                    Serve on <port>, 8080 by default.
+--tls-cert=<file>                Serve HTTPS with the certificate in <file>, needs --tls-key.
+--tls-key=<file>                 The key of the --tls-cert certificate.
+--session-secret=<secret>        Sign session cookies with <secret>, keeping players signed in across restarts. A random
+                                 secret is used otherwise. Command lines can be seen by other users, prefer --config.
+--secure-cookies                 Only send session cookies over HTTPS.
+--cookie-same-site=<mode>        SameSite mode of session cookies: lax, strict or none, lax by default.
+--lobby=<name>                   Play the games of a lobby of the server instead of its default games.
+--create-lobby=<name>            Create a lobby of the server with generated games, or the games saved to --puzzles.
+--list-lobbies                   Print the lobbies of the server and their players.
+--admin-key=<key>                Key lobbies are created with, the server doesn't let anyone create lobbies without one.
+                                 A server's admin key overrides the one in --config.
 --gen=<number-of-games>          Generate <number-of-games> games.
+--puzzles=<file>                 Play the games saved to <file> with --save-puzzles, with the rules they were saved with.
+--save-puzzles=<file>            Save the games, including their secrets, to <file> to play them again.
 --n-cards=<number-of-cards>      Generate games with <number-of-cards> verifiers.
 --min-solutions=<min-solutions>  Generate games with at least <min-solutions> solutions.
+--questions-per-round=<n>        Generated games allow <n> verifiers to be tested each round, 3 by default.
+--guess-mid-round                Generated games allow guessing without ending the round.
 --solver=<solvers>               Use indicated solvers.
+--optimal                        Compare solvers against optimal play on each generated or loaded game.
+--events=<file>                  Write every solver event to <file> as JSON lines.
+--summary                        Print a summary line for every game solved.
+--record=<file>                  Write the puzzle and every move of each generated game to <file> as JSON lines.
+--replay=<file>                  Replay the games recorded to <file> round by round.
+--game=<n>                       Only replay or watch game <n>.
+--watch                          Print the moves made in the games of the server as they are made. Answers, guesses and
+                                 rankings are hidden until every player has guessed the game.
+--player=<name>                  Only watch the moves of player <name>.
+--key=<key>                      Join with the key registered for the solver's name, or rejoin with the token printed when
+                                 it joined to continue its games. Watching with the token shows the player's own moves,
+                                 and the games it has guessed without waiting for the other players.
+--explain                        Explain why every code and verifier was chosen.
+--lie=<probability>              Generated games answer wrong with <probability>, try --solver="best(noise=reask)".
 --profile					     Run with CPU profiler.`
 
 func main() {
@@ -63,20 +121,49 @@ func main() {
 		return
 	}
 
-	var solvers []*solver.Solver
-	solverOpt := opts["--solver"]
-	if solverOpt == nil {
-		solvers = []*solver.Solver{solver.FromString("best")}
-	} else {
-		solvers = make([]*solver.Solver, len(solverOpt.([]string)))
-		for i, solverName := range solverOpt.([]string) {
-			solvers[i] = solver.FromString(solverName)
+	listSolvers, _ := opts.Bool("--list-solvers")
+	if listSolvers {
+		printSolvers()
+		return
+	}
+
+	var resumed *savedGame
+	resumeFile, _ := opts.String("--resume")
+	if resumeFile != "" {
+		saved, err := loadGame(resumeFile)
+		if err != nil {
+			log.Fatal("Resuming : ", err)
+		}
+		resumed = &saved
+	}
+
+	solverSpecs := []string{"best"}
+	interactive, _ := opts.Bool("--interactive")
+	hint, _ := opts.Bool("--hint")
+	if interactive || hint || resumed != nil {
+		// People mistype answers
+		solverSpecs = []string{"best(noise=reask)"}
+	}
+	if resumed != nil && resumed.Solver != "" {
+		solverSpecs = []string{resumed.Solver}
+	}
+	if solverOpt := opts["--solver"]; solverOpt != nil && len(solverOpt.([]string)) > 0 {
+		solverSpecs = solverOpt.([]string)
+	}
+
+	solvers := make([]*solver.Solver, len(solverSpecs))
+	for i, spec := range solverSpecs {
+		solvers[i], err = solver.Parse(spec)
+		if err != nil {
+			log.Fatal("Solver ", spec, " : ", err)
 		}
 	}
 
 	numberOfGamesToGenerate, _ := opts.Int("--gen")
 	runServer, _ := opts.Bool("--server")
 	remoteAdder, _ := opts.String("--remote")
+	puzzlesFile, _ := opts.String("--puzzles")
+	savePuzzlesFile, _ := opts.String("--save-puzzles")
 
 	minSolutions, _ := opts.Int("--min-solutions")
 	if minSolutions == 0 {
@@ -88,107 +175,430 @@ func main() {
 		nVerifiers = 4
 	}
 
-	interactive, _ := opts.Bool("--interactive")
-	if interactive {
-		interactiveGame := createInteractiveGame()
-		interactiveSolver := solvers[0]
-		interactiveSolver.SetProgressCallback(func(progress string) {
-			fmt.Println(progress)
-		})
-		_, solution := interactiveSolver.Solve(interactiveGame)
-		fmt.Println("Solution:", solution)
+	rules := game.DefaultRules
+	if questionsPerRound, _ := opts.Int("--questions-per-round"); questionsPerRound > 0 {
+		rules.QuestionsPerRound = questionsPerRound
+	}
+	rules.GuessMidRound, _ = opts.Bool("--guess-mid-round")
+
+	if eventsFile, _ := opts.String("--events"); eventsFile != "" {
+		events, err := os.Create(eventsFile)
+		if err != nil {
+			log.Fatal("Creating events file : ", err)
+		}
+		defer events.Close()
+
+		eventsObserver := solver.JSONObserver(events)
+		for i := range solvers {
+			solvers[i] = solvers[i].WithObserver(eventsObserver)
+		}
+	}
+
+	if explain, _ := opts.Bool("--explain"); explain {
+		explainObserver := solver.ExplainObserver(os.Stdout)
+		for i := range solvers {
+			solvers[i] = solvers[i].WithExplanations().WithObserver(explainObserver)
+		}
+	}
+
+	if summary, _ := opts.Bool("--summary"); summary {
+		summaryObserver := solver.SummaryObserver(os.Stdout)
+		for i := range solvers {
+			solvers[i] = solvers[i].WithObserver(summaryObserver)
+		}
+	}
+
+	var recorder *game.Recorder
+	if recordFile, _ := opts.String("--record"); recordFile != "" {
+		record, err := os.Create(recordFile)
+		if err != nil {
+			log.Fatal("Creating game log : ", err)
+		}
+		defer record.Close()
+
+		recorder = game.NewRecorder(record)
+	}
+
+	if replayFile, _ := opts.String("--replay"); replayFile != "" {
+		gameNumber, _ := opts.Int("--game")
+		if err := replay(replayFile, gameNumber); err != nil {
+			log.Fatal("Replaying : ", err)
+		}
+	} else if hint {
+		cardNumbers, _ := opts.String("--cards")
+		answersFile, _ := opts.String("--answers")
+		answers, _ := opts["--answer"].([]string)
+		if err := printHint(cardNumbers, answersFile, answers, solvers[0]); err != nil {
+			log.Fatal("Hint : ", err)
+		}
+	} else if interactive || resumed != nil {
+		saveFile, _ := opts.String("--save")
+		if resumed != nil {
+			saveFile = resumeFile
+		}
+
+		useTUI, _ := opts.Bool("--tui")
+		if explain, _ := opts.Bool("--explain"); explain && useTUI {
+			log.Fatal("--explain writes to the terminal the board is drawn on, it can't be used with --tui")
+		}
+
+		result, err := playInteractive(context.Background(), solvers[0], solverSpecs[0], saveFile, resumed, useTUI)
+		if errors.Is(err, game.ErrQuit) {
+			if saveFile != "" {
+				fmt.Printf("Game saved, continue with --resume=%v\n", saveFile)
+			}
+			return
+		} else if err != nil {
+			log.Fatal("Solving : ", err)
+		}
+		fmt.Println("Solution:", game.Solution{Code: result.Code, Verifiers: result.Verifiers})
+	} else if practiceMode, _ := opts.Bool("--practice"); practiceMode {
+		if err := practice(context.Background(), nVerifiers, minSolutions, rules, solvers[0]); err != nil {
+			log.Fatal("Practice : ", err)
+		}
 	} else if runServer {
-		fmt.Println("Generating Games...")
-		games := generateGames(numberOfGamesToGenerate, nVerifiers, minSolutions)
+		config, err := serverConfig(opts)
+		if err != nil {
+			log.Fatal(err)
+		} else if err := config.Validate(); err != nil {
+			log.Fatal(err)
+		}
+
+		// A server with a state restores its games from it
+		var games []game.Game
+		stateFile, _ := opts.String("--state")
+		if _, err := os.Stat(stateFile); stateFile == "" || err != nil {
+			if numberOfGamesToGenerate == 0 && puzzlesFile == "" {
+				log.Fatal("No state in ", stateFile, ", start the server with --gen or --puzzles")
+			}
+
+			games, err = loadOrGenerateGames(puzzlesFile, savePuzzlesFile, numberOfGamesToGenerate, nVerifiers, minSolutions, rules)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			fmt.Println("Restoring", stateFile)
+		}
+
+		gameServer := server.NewGameServer(games, config.AdminKey)
+		if stateFile != "" {
+			if err := gameServer.Persist(stateFile); err != nil {
+				log.Fatal("Restoring state : ", err)
+			}
+		}
+		if recorder != nil {
+			gameServer.Record(recorder)
+		}
 		fmt.Println("Starting Server...")
-		gameServer := server.NewGameServer(games)
-		gameServer.Listen()
+		ctx, stop := interruptible()
+		defer stop()
+
+		if err := gameServer.Listen(ctx, config); err != nil {
+			log.Fatal(err)
+		}
+	} else if lobbyName, _ := opts.String("--create-lobby"); remoteAdder != "" && lobbyName != "" {
+		request := types.CreateLobbyRequest{Name: lobbyName, Games: numberOfGamesToGenerate, NCards: nVerifiers, MinSolutions: minSolutions, Rules: &rules}
+		if puzzlesFile != "" {
+			if request.Puzzles, err = game.ReadPuzzles(puzzlesFile); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		adminKey, _ := opts.String("--admin-key")
+		lobby, err := game.CreateLobby(remoteAdder, adminKey, request)
+		if err != nil {
+			log.Fatal("Creating lobby : ", err)
+		}
+		fmt.Printf("Created lobby %v with %v games, join with --lobby=%v\n", lobby.Name, lobby.Games, lobby.Name)
+	} else if listLobbies, _ := opts.Bool("--list-lobbies"); remoteAdder != "" && listLobbies {
+		lobbies, err := game.ListLobbies(remoteAdder)
+		if err != nil {
+			log.Fatal("Listing lobbies : ", err)
+		}
+		for _, lobby := range lobbies {
+			fmt.Printf("%v: %v games, players %v\n", lobby.Name, lobby.Games, strings.Join(lobby.Players, ", "))
+		}
+	} else if watch, _ := opts.Bool("--watch"); remoteAdder != "" && watch {
+		ctx, stop := interruptible()
+		defer stop()
+
+		lobbyName, _ := opts.String("--lobby")
+		gameNumber, _ := opts.Int("--game")
+		filter := types.WatchFilter{GameIndex: gameNumber - 1}
+		filter.Player, _ = opts.String("--player")
+		key, _ := opts.String("--key")
+		err := game.WatchLobby(ctx, remoteAdder, lobbyName, key, filter, func(event types.GameEvent) {
+			fmt.Println(game.FormatEvent(event))
+		})
+		if err != nil {
+			log.Fatal("Watching : ", err)
+		}
 	} else if remoteAdder != "" {
+		ctx, stop := interruptible()
+		defer stop()
+
+		lobbyName, _ := opts.String("--lobby")
+		key, _ := opts.String("--key")
+		if key != "" && len(solvers) > 1 {
+			log.Fatal("A key joins one solver, pick it with --solver")
+		}
+
 		wg := sync.WaitGroup{}
 		for _, solverToUse := range solvers {
-			remoteGames, err := game.JoinGames(remoteAdder, solverToUse.GetPlayerName())
+			remoteGames, token, err := game.Join(remoteAdder, types.JoinRequest{PlayerName: solverToUse.GetPlayerName(), Lobby: lobbyName, Key: key})
 			if err != nil {
 				log.Fatal("Joining games : ", err)
 			}
+			if token != "" {
+				fmt.Printf("Joined as %v, rejoin with --key=%v\n", solverToUse.GetPlayerName(), token)
+			}
 
-			wg.Add(1)
-			go func(solverToUse *solver.Solver) {
-				defer wg.Done()
-				for _, remoteGame := range remoteGames {
-					correct, _ := solverToUse.Solve(remoteGame)
-					if !correct {
-						fmt.Println("Solver", solverToUse.GetPlayerName(), "failed to solve")
-					}
+			for _, remoteGame := range remoteGames {
+				if remoteGame.(*game.RemoteGame).Guessed() {
+					continue
 				}
-			}(solverToUse)
+
+				wg.Add(1)
+				go func(solverToUse *solver.Solver, remoteGame *game.RemoteGame) {
+					defer wg.Done()
+					// A solver that rejoins continues from the answers it got before
+					session := solverToUse.NewSession(remoteGame)
+					for _, answer := range remoteGame.Answers() {
+						if err := session.Apply(answer); err != nil {
+							fmt.Println("Solver", solverToUse.GetPlayerName(), "failed to continue:", err)
+							return
+						}
+					}
+
+					result, err := session.Solve(ctx)
+					if errors.Is(err, context.Canceled) {
+						return
+					} else if err != nil {
+						fmt.Println("Solver", solverToUse.GetPlayerName(), "failed to solve:", err)
+					} else if !result.Correct {
+						fmt.Println("Solver", solverToUse.GetPlayerName(), "guessed wrong:", result.Code)
+					}
+				}(solverToUse, remoteGame.(*game.RemoteGame))
+			}
 		}
 
 		wg.Wait()
-	} else if numberOfGamesToGenerate > 0 {
-		evaluateSolvers(numberOfGamesToGenerate, nVerifiers, minSolutions, solvers)
+	} else if numberOfGamesToGenerate > 0 || puzzlesFile != "" {
+		games, err := loadOrGenerateGames(puzzlesFile, savePuzzlesFile, numberOfGamesToGenerate, nVerifiers, minSolutions, rules)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		lieProbability := 0.0
+		if lie, _ := opts.String("--lie"); lie != "" {
+			lieProbability, err = strconv.ParseFloat(lie, 64)
+			if err != nil {
+				log.Fatal("Parsing --lie : ", err)
+			}
+		}
+
+		ctx, stop := interruptible()
+		defer stop()
+
+		evaluateSolvers(ctx, games, lieProbability, recorder, solvers)
+		if optimal, _ := opts.Bool("--optimal"); optimal && ctx.Err() == nil {
+			reportOptimalPlay(games, solvers)
+		}
 	}
 }
 
-func evaluateSolvers(numberOfGamesToGenerate int, nVerifiers int, minSolutions int, solvers []*solver.Solver) {
-	fmt.Println("Generating Games...")
-	games := generateGames(numberOfGamesToGenerate, nVerifiers, minSolutions)
+// evaluateSolvers has every solver play the same games, the moves made in them are recorded when recorder isn't nil
+func evaluateSolvers(ctx context.Context, games []game.Game, lieProbability float64, recorder *game.Recorder, solvers []*solver.Solver) {
+	if lieProbability > 0 {
+		for i, generated := range games {
+			generated.(*game.AutoGame).SetLieProbability(lieProbability, int64(i))
+		}
+	}
 	fmt.Println("Solving...")
 	gameWaitGroup := sync.WaitGroup{}
 	for _, gameToSolve := range games {
+		if recorder != nil {
+			gameToSolve = recorder.Record(gameToSolve)
+		}
 		for _, competingSolver := range solvers {
 			gameWaitGroup.Add(1)
 			go func(competingSolver *solver.Solver, gameToSolve game.Game) {
 				defer gameWaitGroup.Done()
-				singleSolver := *competingSolver
-				correct, solution := singleSolver.Solve(gameToSolve)
-				if !correct {
-					fmt.Println("Solver", competingSolver.GetPlayerName(), "failed to solve:", solution.Code)
+				result, err := competingSolver.SolveContext(ctx, gameToSolve)
+				if errors.Is(err, context.Canceled) {
+					return
+				} else if err != nil {
+					fmt.Println("Solver", competingSolver.GetPlayerName(), "failed to solve:", err)
+				} else if !result.Correct {
+					fmt.Println("Solver", competingSolver.GetPlayerName(), "guessed wrong:", result.Code)
 				}
 			}(competingSolver, gameToSolve)
 		}
 	}
 
 	gameWaitGroup.Wait()
+	if ctx.Err() != nil {
+		fmt.Println("Interrupted")
+		return
+	}
 	game.PrintWinCount(games)
 }
 
-func generateGames(numberOfGamesToGenerate, nVerifiers, minSolutions int) []game.Game {
-	games := make(chan game.Game, numberOfGamesToGenerate/10+1)
-	wg := sync.WaitGroup{}
+// serverConfig reads the --config file and overrides it with the listener flags given
+func serverConfig(opts docopt.Opts) (server.Config, error) {
+	config := server.Config{}
+	if configFile, _ := opts.String("--config"); configFile != "" {
+		var err error
+		if config, err = server.LoadConfig(configFile); err != nil {
+			return config, err
+		}
+	}
+
+	if address, _ := opts.String("--address"); address != "" {
+		config.Address = address
+	}
+	if port, _ := opts.String("--port"); port != "" {
+		var err error
+		if config.Port, err = strconv.Atoi(port); err != nil {
+			return config, fmt.Errorf("parsing --port : %w", err)
+		}
+	}
+	if tlsCert, _ := opts.String("--tls-cert"); tlsCert != "" {
+		config.TLSCert = tlsCert
+	}
+	if tlsKey, _ := opts.String("--tls-key"); tlsKey != "" {
+		config.TLSKey = tlsKey
+	}
+	if secret, _ := opts.String("--session-secret"); secret != "" {
+		config.SessionSecret = secret
+	}
+	if secure, _ := opts.Bool("--secure-cookies"); secure {
+		config.SecureCookies = true
+	}
+	if sameSite, _ := opts.String("--cookie-same-site"); sameSite != "" {
+		config.CookieSameSite = sameSite
+	}
+	if adminKey, _ := opts.String("--admin-key"); adminKey != "" {
+		config.AdminKey = adminKey
+	}
 
-	for i := 0; i < numberOfGamesToGenerate; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			games <- game_generator.GenerateGame(nVerifiers, minSolutions)
-		}()
+	return config, nil
+}
+
+// loadOrGenerateGames loads the games saved to puzzlesFile, or generates them when it is empty. The games are saved to
+// savePuzzlesFile when it isn't empty.
+func loadOrGenerateGames(puzzlesFile string, savePuzzlesFile string, numberOfGamesToGenerate, nVerifiers, minSolutions int, rules game.Rules) ([]game.Game, error) {
+	var games []game.Game
+	if puzzlesFile != "" {
+		fmt.Println("Loading Games...")
+		var err error
+		if games, err = game.LoadPuzzles(puzzlesFile); err != nil {
+			return nil, fmt.Errorf("loading games : %w", err)
+		}
+	} else {
+		fmt.Println("Generating Games...")
+		games = game_generator.GenerateGames(numberOfGamesToGenerate, nVerifiers, minSolutions, rules)
+	}
 
+	if savePuzzlesFile != "" {
+		if err := game.SavePuzzles(savePuzzlesFile, games); err != nil {
+			return nil, err
+		}
+		fmt.Println("Saved", len(games), "games to", savePuzzlesFile)
 	}
 
-	go func() {
-		wg.Wait()
-		close(games)
-	}()
+	return games, nil
+}
+
+// interruptible returns a context canceled by Ctrl-C or SIGTERM. Only modes that don't read stdin use it, the others
+// keep the default handling so Ctrl-C still stops them while they wait for input.
+func interruptible() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// playInteractive solves a game answered at the terminal, or on a full screen board with useTUI, saving it to saveFile
+// after every answer when saveFile isn't empty. A resumed game is given its answers before any more questions are asked,
+// the same as a game whose answers were edited at the prompt.
+func playInteractive(ctx context.Context, interactiveSolver *solver.Solver, solverSpec string, saveFile string, resumed *savedGame, useTUI bool) (solver.Result, error) {
+	var cards []*verifiers.VerifierCard
+	saved := savedGame{Solver: solverSpec}
+	if resumed != nil {
+		var err error
+		if cards, err = resumed.cards(); err != nil {
+			return solver.Result{}, err
+		}
+		saved.Cards, saved.Answers = resumed.Cards, resumed.Answers
+	} else {
+		cards = createInteractiveGame().GetVerifierCards()
+		saved.Cards = cardNumbers(cards)
+	}
 
-	result := make([]game.Game, 0, numberOfGamesToGenerate)
-	for g := range games {
-		result = append(result, g)
+	newGame := game.NewInteractiveGame
+	replaying := false
+	reportSaveError := func(err error) { fmt.Println("Saving game :", err) }
+	if useTUI {
+		// The board shows the solutions itself, and save errors as anything printed would be drawn over
+		board, err := tui.NewBoard(cards)
+		if err != nil {
+			return solver.Result{}, err
+		}
+		defer board.Close()
+		newGame = func([]*verifiers.VerifierCard) game.Game { return board }
+		reportSaveError = func(err error) { board.SetStatus("Saving game : " + err.Error()) }
+	} else {
+		// Solutions are only shown once the answers so far have been replayed
+		textObserver := solver.TextObserver(os.Stdout)
+		interactiveSolver = interactiveSolver.WithObserver(solver.ObserverFunc(func(s *solver.Session, event solver.Event) {
+			if !replaying {
+				textObserver.Observe(s, event)
+			}
+		}))
+	}
+	if saveFile != "" {
+		interactiveSolver = interactiveSolver.WithObserver(saveObserver(saveFile, saved, reportSaveError))
 	}
 
-	return result
+	for {
+		if saveFile != "" {
+			if err := saved.save(saveFile); err != nil {
+				return solver.Result{}, err
+			}
+		}
+
+		session := interactiveSolver.NewSession(newGame(cards))
+		if len(saved.Answers) > 0 && !useTUI {
+			fmt.Printf("Replaying %v answers\n", len(saved.Answers))
+		}
+
+		replaying = true
+		for _, answer := range saved.Answers {
+			if err := session.Apply(answer); err != nil {
+				return solver.Result{}, err
+			}
+		}
+		replaying = false
+
+		result, err := session.Solve(ctx)
+		var edited *game.AnswersEdited
+		if !errors.As(err, &edited) {
+			return result, err
+		}
+		saved.Answers = edited.Answers
+	}
 }
 
 func createInteractiveGame() game.Game {
 	const prompt = "Add verifiers (blank to stop, - to remove previous): "
 	cards := []*verifiers.VerifierCard{}
-	reader := bufio.NewScanner(os.Stdin)
 	for {
 		fmt.Print(prompt)
-		if !reader.Scan() {
+		input, err := game.ReadLine()
+		if err == io.EOF {
 			break
+		} else if err != nil {
+			log.Fatal(fmt.Errorf("reading input : %w", err))
 		}
 
-		input := reader.Text()
 		input = strings.TrimSpace(input)
 
 		if input == "" {
@@ -206,40 +616,50 @@ func createInteractiveGame() game.Game {
 			continue
 		}
 
-		if cardNumber > 1002 { // 1002 is the lowest Extreme code (low number is always first)
-			lowNumber := cardNumber / 1000
-			highNumber := cardNumber % 1000
-
-			if verifyCardNumber(lowNumber) && verifyCardNumber(highNumber) {
-				fmt.Println("Adding XTREAM card:", lowNumber, ":", highNumber)
-				xtreamCard := verifiers.Cards[lowNumber-1].Combine(verifiers.Cards[highNumber-1])
-				cards = append(cards, &xtreamCard)
-			} else {
-				fmt.Println("No XTREAM card with that number:", cardNumber, ":", lowNumber, ":", highNumber)
-				continue
-			}
-		} else if verifyCardNumber(cardNumber) {
-			fmt.Println("Adding: ", verifiers.Cards[cardNumber-1])
-			cards = append(cards, &verifiers.Cards[cardNumber-1])
-		} else {
-			fmt.Println("No card with that number")
+		card, err := verifiers.CardFromNumber(cardNumber)
+		if err != nil {
+			fmt.Println(err)
 			continue
 		}
-	}
 
-	if reader.Err() != nil {
-		log.Fatal(fmt.Errorf("reading input : %w", reader.Err()))
+		fmt.Println("Adding: ", card)
+		cards = append(cards, card)
 	}
 
 	return game.NewInteractiveGame(cards)
 }
 
-func verifyCardNumber(cardNumber int) bool {
-	return cardNumber <= len(verifiers.Cards) && cardNumber > 0
-}
-
 func printAllVerifierCards() {
 	for i, card := range verifiers.Cards {
 		fmt.Printf("%v: %v\n", i, card)
 	}
 }
+
+func printSolvers() {
+	printRegistrations("Solvers", solver.Solvers())
+	printRegistrations("Code strategies (code=)", solver.CodeStrategies())
+	printRegistrations("Verifier strategies (verifier=)", solver.VerifierStrategies())
+	printRegistrations("Combined strategies (combined=)", solver.CombinedStrategies())
+
+	fmt.Println("Options for every solver:")
+	printParams(solver.SolverParams())
+}
+
+func printRegistrations(title string, registrations []solver.Registration) {
+	fmt.Println(title + ":")
+	for _, registration := range registrations {
+		fmt.Printf("  %-16v %v\n", registration.Name, registration.Description)
+		printParams(registration.Params)
+	}
+	fmt.Println()
+}
+
+func printParams(params []solver.Param) {
+	for _, param := range params {
+		defaultValue := ""
+		if param.Default != "" {
+			defaultValue = " (default " + param.Default + ")"
+		}
+		fmt.Printf("    %-15v %v%v\n", param.Name+"=", param.Description, defaultValue)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/solver"
+)
+
+// reportOptimalPlay prints how each solver did on each game compared to optimal play, and totals across all games. Only
+// games with a known secret are compared, the others are reported as skipped.
+func reportOptimalPlay(games []game.Game, solvers []*solver.Solver) {
+	fmt.Println("Finding optimal play...")
+	optimalPlays := make([]solver.OptimalPlay, len(games))
+	skipped := make([]bool, len(games))
+	gameIndexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < runtime.NumCPU(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for gameIndex := range gameIndexes {
+				autoGame, ok := games[gameIndex].(*game.AutoGame)
+				if !ok {
+					skipped[gameIndex] = true
+					continue
+				}
+
+				secret := autoGame.Solution()
+				optimalPlay, err := solver.Optimal(autoGame.GetVerifierCards(), autoGame.Rules(), &secret)
+				if err != nil {
+					log.Fatal("Finding optimal play : ", err)
+				}
+
+				optimalPlays[gameIndex] = optimalPlay
+			}
+		}()
+	}
+
+	for gameIndex := range games {
+		gameIndexes <- gameIndex
+	}
+	close(gameIndexes)
+	wg.Wait()
+
+	type totals struct {
+		cost          solver.Cost
+		solved        int
+		optimal       int
+		withinOptimal int
+	}
+	solverTotals := make([]totals, len(solvers))
+	var optimalTotal, worstCaseTotal solver.Cost
+	compared := 0
+
+	fmt.Println("Optimal play:")
+	for gameIndex, gameToReport := range games {
+		if skipped[gameIndex] {
+			fmt.Printf("\tGame %v: skipped, its secret isn't known\n", gameIndex+1)
+			continue
+		}
+
+		compared += 1
+		optimalPlay := optimalPlays[gameIndex]
+		optimalTotal = addCost(optimalTotal, optimalPlay.Secret)
+		worstCaseTotal = addCost(worstCaseTotal, optimalPlay.WorstCase)
+		fmt.Printf("\tGame %v: best for secret %v, best worst case %v\n", gameIndex+1, optimalPlay.Secret, optimalPlay.WorstCase)
+
+		stats := gameToReport.(*game.AutoGame).Stats()
+		for solverIndex, competingSolver := range solvers {
+			cost, solved := solverCost(stats, competingSolver.GetPlayerName())
+			if !solved {
+				fmt.Printf("\t\t%v: did not solve\n", competingSolver.GetPlayerName())
+				continue
+			}
+
+			solverTotal := &solverTotals[solverIndex]
+			solverTotal.cost = addCost(solverTotal.cost, cost)
+			solverTotal.solved += 1
+			if cost == optimalPlay.Secret {
+				solverTotal.optimal += 1
+			}
+			if !optimalPlay.WorstCase.Less(cost) {
+				solverTotal.withinOptimal += 1
+			}
+
+			fmt.Printf("\t\t%v: %v (+%v codes, +%v questions)\n", competingSolver.GetPlayerName(), cost,
+				cost.Codes-optimalPlay.Secret.Codes, cost.Questions-optimalPlay.Secret.Questions)
+		}
+	}
+
+	fmt.Println("Average over", compared, "games:")
+	fmt.Printf("\tBest for secret: %.2f codes, %.2f questions\n", average(optimalTotal.Codes, compared), average(optimalTotal.Questions, compared))
+	fmt.Printf("\tBest worst case: %.2f codes, %.2f questions\n", average(worstCaseTotal.Codes, compared), average(worstCaseTotal.Questions, compared))
+	for solverIndex, competingSolver := range solvers {
+		solverTotal := solverTotals[solverIndex]
+		fmt.Printf("\t%v: %.2f codes, %.2f questions, solved %v, best for secret on %v, within best worst case on %v\n",
+			competingSolver.GetPlayerName(), average(solverTotal.cost.Codes, solverTotal.solved), average(solverTotal.cost.Questions, solverTotal.solved),
+			solverTotal.solved, solverTotal.optimal, solverTotal.withinOptimal)
+	}
+}
+
+// solverCost returns the codes and questions used by the named player, if it guessed correctly
+func solverCost(stats map[game.Player]*game.PlayerMoves, playerName string) (solver.Cost, bool) {
+	for player, moves := range stats {
+		if player.GetPlayerName() != playerName {
+			continue
+		}
+
+		correct, guessed := moves.GuessedCorrectly()
+		return solver.Cost{Codes: moves.CodesTested(), Questions: moves.QuestionsAsked()}, correct && guessed
+	}
+
+	return solver.Cost{}, false
+}
+
+func addCost(a, b solver.Cost) solver.Cost {
+	return solver.Cost{Codes: a.Codes + b.Codes, Questions: a.Questions + b.Questions}
+}
+
+func average(total, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+
+	return float64(total) / float64(count)
+}
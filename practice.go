@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/game_generator"
+	"github.com/caseymerrill/turingsolver/solver"
+)
+
+const practiceHelp = `Commands:
+  <code> <verifiers>  Test a code against verifiers, e.g. 245 1 3, another code starts a new round
+  end                 End the round, to test the same code again
+  guess <code>        Guess the code and end the game
+  cards               Show the verifier cards
+  history             Show the answers so far
+  quit                Stop without guessing`
+
+// humanPlayer is the person playing a practice game
+type humanPlayer struct{}
+
+func (humanPlayer) GetPlayerName() string {
+	return "You"
+}
+
+// practice has a person play a generated game at the terminal, then has the solver play the same game and compares them
+func practice(ctx context.Context, nVerifiers int, minSolutions int, rules game.Rules, opponent *solver.Solver) error {
+	fmt.Println("Generating Game...")
+	practiceGame := game_generator.GenerateGame(nVerifiers, minSolutions).(*game.AutoGame)
+	practiceGame.SetRules(rules)
+	nCards := len(practiceGame.GetVerifierCards())
+	fmt.Print(practiceGame)
+	fmt.Println(practiceHelp)
+
+	player := humanPlayer{}
+	var answers []game.Answer
+
+	// roundCode is the code of the round in progress, nil between rounds
+	var roundCode []int
+	for {
+		fmt.Print("> ")
+		line, err := game.ReadLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading input : %w", err)
+		}
+
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "?", "help":
+			fmt.Println(practiceHelp)
+			continue
+		case "cards":
+			fmt.Print(practiceGame)
+			continue
+		case "history":
+			for i, answer := range answers {
+				fmt.Printf("  %v: %v\n", i+1, answer)
+			}
+			continue
+		case "q", "quit":
+			return nil
+		case "end":
+			if roundCode == nil {
+				fmt.Println("No round to end")
+			} else if err := practiceGame.EndRound(player); err != nil {
+				return err
+			}
+			roundCode = nil
+			continue
+		case "guess":
+			if len(fields) != 2 {
+				fmt.Println("Usage: guess <code>")
+				continue
+			}
+
+			code, err := game.ParseCode(fields[1])
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+
+			if roundCode != nil && !practiceGame.Rules().GuessMidRound {
+				if err := practiceGame.EndRound(player); err != nil {
+					return err
+				}
+			}
+
+			if correct, err := practiceGame.MakeGuess(player, code); err != nil {
+				return err
+			} else if correct {
+				fmt.Println("Correct!")
+			} else {
+				fmt.Println("Wrong, the solution was", practiceGame.Solution())
+			}
+			return comparePractice(ctx, practiceGame, player, opponent)
+		}
+
+		code, verifierIndexes, err := parseQuestions(fields, nCards)
+		if err != nil {
+			fmt.Println(err, "(? for commands)")
+			continue
+		}
+
+		if !slices.Equal(roundCode, code) {
+			if roundCode != nil {
+				if err := practiceGame.EndRound(player); err != nil {
+					return err
+				}
+			}
+
+			if err := practiceGame.StartRound(player, code); err != nil {
+				return err
+			}
+			roundCode = code
+			fmt.Printf("Round %v, testing code %v\n", practiceGame.Stats()[player].CodesTested(), game.CodeString(code))
+		}
+
+		for _, verifierIndex := range verifierIndexes {
+			// The game explains moves the rules don't allow, such as a fourth question in a round
+			valid, err := practiceGame.AskQuestion(player, code, verifierIndex)
+			if errors.Is(err, game.ErrRuleViolation) {
+				fmt.Println(err)
+				break
+			} else if err != nil {
+				return err
+			}
+
+			answer := game.Answer{Code: code, Verifier: verifierIndex, Valid: valid}
+			answers = append(answers, answer)
+
+			result := "fails"
+			if answer.Valid {
+				result = "passes"
+			}
+			fmt.Printf("Code %v %v verifier %v\n", game.CodeString(code), result, verifierIndex+1)
+		}
+	}
+}
+
+// comparePractice has the solver play the game the person just played and ranks them
+func comparePractice(ctx context.Context, practiceGame *game.AutoGame, player game.Player, opponent *solver.Solver) error {
+	session := opponent.NewSession(practiceGame)
+	if _, err := session.Solve(ctx); err != nil {
+		return fmt.Errorf("%v playing the same game : %w", opponent.GetPlayerName(), err)
+	}
+
+	fmt.Printf("\n%v played:\n", opponent.GetPlayerName())
+	for i, answer := range session.Answers() {
+		fmt.Printf("  %v: %v\n", i+1, answer)
+	}
+	fmt.Println()
+
+	stats := practiceGame.Stats()
+	for _, playerToReport := range []game.Player{player, session} {
+		moves := stats[playerToReport]
+		outcome := "guessed wrong"
+		if correct, _ := moves.GuessedCorrectly(); correct {
+			outcome = "guessed correctly"
+		}
+		fmt.Printf("%v: %v codes, %v questions, %v\n", playerToReport.GetPlayerName(), moves.CodesTested(), moves.QuestionsAsked(), outcome)
+	}
+
+	rank := practiceGame.Rank()
+	if len(rank) == 0 {
+		fmt.Println("Nobody found the code")
+	} else if len(rank[0]) > 1 {
+		fmt.Println("It's a tie")
+	} else {
+		fmt.Println(rank[0][0].GetPlayerName(), "won")
+	}
+
+	return nil
+}
+
+// parseQuestions reads a code followed by verifiers numbered from 1
+func parseQuestions(fields []string, nCards int) ([]int, []int, error) {
+	code, err := game.ParseCode(fields[0])
+	if err != nil {
+		return nil, nil, err
+	} else if len(fields) == 1 {
+		return nil, nil, fmt.Errorf("choose verifiers to test the code against")
+	}
+
+	verifierIndexes := make([]int, len(fields)-1)
+	for i, field := range fields[1:] {
+		verifier, err := strconv.Atoi(field)
+		if err != nil || verifier < 1 || verifier > nCards {
+			return nil, nil, fmt.Errorf("verifier must be 1 to %v: %q", nCards, field)
+		}
+		verifierIndexes[i] = verifier - 1
+	}
+
+	return code, verifierIndexes, nil
+}
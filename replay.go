@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/solver"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// replayPlayer stands in for a player named in a game log
+type replayPlayer struct {
+	name string
+}
+
+func (p *replayPlayer) GetPlayerName() string {
+	return p.name
+}
+
+// replay plays the moves of every game in a log written with --record again, or only those of gameNumber when it isn't
+// 0, and prints them round by round with the solutions each player had left
+func replay(filename string, gameNumber int) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("opening game log : %w", err)
+	}
+	defer file.Close()
+
+	entries, err := game.ReadLog(file)
+	if err != nil {
+		return err
+	}
+
+	// Moves are logged as they are made, so players of the same game are mixed together
+	headers := make(map[int]game.LogEntry)
+	var gameIDs []int
+	moves := make(map[int][]game.LogEntry)
+	for _, entry := range entries {
+		if gameNumber != 0 && entry.Game != gameNumber {
+			continue
+		} else if entry.Kind == game.LogGame {
+			headers[entry.Game] = entry
+			gameIDs = append(gameIDs, entry.Game)
+		} else {
+			moves[entry.Game] = append(moves[entry.Game], entry)
+		}
+	}
+
+	if len(gameIDs) == 0 {
+		return fmt.Errorf("no games to replay in %v", filename)
+	}
+
+	for _, gameID := range gameIDs {
+		if err := replayGame(headers[gameID], moves[gameID]); err != nil {
+			return fmt.Errorf("game %v : %w", gameID, err)
+		}
+	}
+
+	return nil
+}
+
+// replayGame plays the moves of each player again against the puzzle of header, flagging answers that aren't the ones
+// the puzzle gives. Games logged without their secret are only printed.
+func replayGame(header game.LogEntry, moves []game.LogEntry) error {
+	cards := make([]*verifiers.VerifierCard, len(header.Cards))
+	for i, cardNumber := range header.Cards {
+		card, err := verifiers.CardFromNumber(cardNumber)
+		if err != nil {
+			return err
+		}
+		cards[i] = card
+	}
+
+	var puzzle *game.AutoGame
+	if header.Verifiers != nil {
+		var err error
+		puzzle, err = game.PuzzleGame(game.Puzzle{Cards: header.Cards, Verifiers: header.Verifiers, Code: header.Code, Rules: header.Rules})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Game %v:\n", header.Game)
+	fmt.Print(game.NewInteractiveGame(cards))
+	if puzzle != nil {
+		fmt.Println("Solution:", puzzle.Solution())
+	}
+
+	var playerIDs []int
+	playerMoves := make(map[int][]game.LogEntry)
+	for _, move := range moves {
+		if _, ok := playerMoves[move.PlayerID]; !ok {
+			playerIDs = append(playerIDs, move.PlayerID)
+		}
+		playerMoves[move.PlayerID] = append(playerMoves[move.PlayerID], move)
+	}
+
+	for _, playerID := range playerIDs {
+		player := &replayPlayer{name: playerMoves[playerID][0].Player}
+		fmt.Printf("\n%v (player %v):\n", player.name, playerID)
+		replayPlayerMoves(player, cards, puzzle, playerMoves[playerID])
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// replayPlayerMoves prints the moves of one player, playing them again against puzzle when it isn't nil. The solutions
+// left are those contradicting the fewest answers, so a wrong answer doesn't leave none.
+func replayPlayerMoves(player *replayPlayer, cards []*verifiers.VerifierCard, puzzle *game.AutoGame, moves []game.LogEntry) {
+	wrongAnswers := 0
+	counter := solver.NotImplementedSolver().WithNoise(solver.NoiseReask).WithObserver(solver.ObserverFunc(func(s *solver.Session, event solver.Event) {
+		if contradiction, ok := event.(solver.ContradictionDetected); ok {
+			wrongAnswers = contradiction.WrongAnswers
+		}
+	}))
+	session := counter.NewSession(game.NewInteractiveGame(cards))
+	questions := 0
+	for _, move := range moves {
+		var result bool
+		var err error
+		switch move.Kind {
+		case game.LogStartRound:
+			fmt.Printf("  Round %v, code %v\n", move.Round, game.CodeString(move.Code))
+			if puzzle != nil {
+				err = puzzle.StartRound(player, move.Code)
+			}
+		case game.LogEndRound:
+			if puzzle != nil {
+				err = puzzle.EndRound(player)
+			}
+		case game.LogQuestion:
+			questions += 1
+			outcome := "fails"
+			if move.Result {
+				outcome = "passes"
+			}
+			fmt.Printf("    Verifier %v %v", move.Verifier+1, outcome)
+			if puzzle != nil {
+				result, err = puzzle.AskQuestion(player, move.Code, move.Verifier)
+				if err == nil && result != move.Result {
+					fmt.Print(", the puzzle says it doesn't")
+				}
+			}
+
+			if move.Error == "" {
+				answer := game.Answer{Code: move.Code, Verifier: move.Verifier, Valid: move.Result}
+				if applyErr := session.Apply(answer); applyErr != nil {
+					fmt.Print(", ", applyErr)
+				}
+			}
+			fmt.Printf(", %v solutions left", len(session.Solutions()))
+			if wrongAnswers > 0 {
+				fmt.Printf(" if %v answers were wrong", wrongAnswers)
+			}
+			fmt.Println()
+		case game.LogGuess:
+			outcome := "wrong"
+			if move.Result {
+				outcome = "correct"
+			}
+			fmt.Printf("  Guessed %v after %v rounds and %v questions, %v\n", game.CodeString(move.Code), move.Round, questions, outcome)
+			if puzzle != nil {
+				result, err = puzzle.MakeGuess(player, move.Code)
+				if err == nil && result != move.Result {
+					fmt.Println("    The puzzle says the guess was", !move.Result)
+				}
+			}
+		default:
+			fmt.Printf("  Unknown move %q\n", move.Kind)
+		}
+
+		if move.Error != "" {
+			fmt.Println("    Error:", move.Error)
+		}
+		if err != nil && err.Error() != move.Error {
+			fmt.Println("    Replaying gives error:", err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/solver"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// savedGame is an interactive game in progress, written after every answer so it can be resumed
+type savedGame struct {
+	// Cards are the card numbers of the game, XTREAM cards use their combined number
+	Cards   []int         `json:"cards"`
+	Solver  string        `json:"solver"`
+	Answers []game.Answer `json:"answers"`
+}
+
+func loadGame(filename string) (savedGame, error) {
+	saved := savedGame{}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return saved, fmt.Errorf("reading saved game : %w", err)
+	} else if err := json.Unmarshal(data, &saved); err != nil {
+		return saved, fmt.Errorf("parsing saved game %v : %w", filename, err)
+	}
+
+	return saved, nil
+}
+
+// cards returns the verifier cards of the saved game
+func (g savedGame) cards() ([]*verifiers.VerifierCard, error) {
+	cards := make([]*verifiers.VerifierCard, len(g.Cards))
+	for i, cardNumber := range g.Cards {
+		card, err := verifiers.CardFromNumber(cardNumber)
+		if err != nil {
+			return nil, err
+		}
+		cards[i] = card
+	}
+
+	return cards, nil
+}
+
+// save writes the game to a temporary file first so a crash never leaves half a save behind
+func (g savedGame) save(filename string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return fmt.Errorf("saving game : %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("saving game : %w", err)
+	} else if err := temp.Close(); err != nil {
+		return fmt.Errorf("saving game : %w", err)
+	}
+
+	return os.Rename(temp.Name(), filename)
+}
+
+// saveObserver saves the game after every answer the session receives, passing the errors saving it to report
+func saveObserver(filename string, saved savedGame, report func(error)) solver.Observer {
+	return solver.ObserverFunc(func(s *solver.Session, event solver.Event) {
+		if _, ok := event.(solver.AnswerReceived); !ok {
+			return
+		}
+
+		saved.Answers = s.Answers()
+		if err := saved.save(filename); err != nil {
+			report(err)
+		}
+	})
+}
+
+// cardNumbers returns the numbers to save for cards
+func cardNumbers(cards []*verifiers.VerifierCard) []int {
+	numbers := make([]int, len(cards))
+	for i, card := range cards {
+		numbers[i] = card.CardNumber
+	}
+
+	return numbers
+}
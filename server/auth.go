@@ -0,0 +1,103 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenOwner is the player a bearer token was given to
+type tokenOwner struct {
+	lobby  string
+	player string
+}
+
+// newToken returns a random token for a player that joins
+func newToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("making token : %w", err)
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// hashToken returns what is kept of a token, so that neither the server's memory nor its state file give tokens away
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// bearerToken returns the token of the request's Authorization header, empty if it has none
+func bearerToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// setToken makes tokenHash the only token of a player, the token of an earlier join stops working
+func (s *GameServer) setToken(tokenHash string, owner tokenOwner) {
+	s.tokensLock.Lock()
+	defer s.tokensLock.Unlock()
+
+	for hash, tokenOwner := range s.tokens {
+		if tokenOwner == owner {
+			delete(s.tokens, hash)
+		}
+	}
+	s.tokens[tokenHash] = owner
+}
+
+// owner returns the player a token was given to
+func (s *GameServer) owner(token string) (tokenOwner, bool) {
+	s.tokensLock.RLock()
+	defer s.tokensLock.RUnlock()
+
+	owner, ok := s.tokens[hashToken(token)]
+	return owner, ok
+}
+
+// wrongKey returns true if the player's name has a key registered with the server and key isn't it
+func (s *GameServer) wrongKey(playerName string, key string) bool {
+	registered, ok := s.keys[playerName]
+	return ok && subtle.ConstantTimeCompare([]byte(registered), []byte(key)) != 1
+}
+
+// mayRejoin returns true if key shows the player joining is the one that joined with the name before, either with the
+// key registered for the name or the token of the earlier join
+func (s *GameServer) mayRejoin(lobby *Lobby, playerName string, key string) bool {
+	if key == "" {
+		return false
+	} else if _, registered := s.keys[playerName]; registered {
+		return !s.wrongKey(playerName, key)
+	}
+
+	owner, ok := s.owner(key)
+	return ok && owner == tokenOwner{lobby: lobby.name, player: playerName}
+}
+
+// endOpenRounds ends the rounds a player left open, so that a player that crashed mid-round can start it again.
+// The caller holds the lobby's moves and players locks.
+func (s *GameServer) endOpenRounds(lobby *Lobby, playerName string) {
+	player := lobby.players[playerName]
+	for gameIndex, played := range lobby.history[playerName] {
+		if !played.roundOpen {
+			continue
+		}
+
+		if err := lobby.games[gameIndex].EndRound(player); err != nil {
+			fmt.Println("Ending round of", playerName, "rejoining :", err)
+			continue
+		}
+		lobby.history[playerName][gameIndex].roundOpen = false
+		s.persist(storeEntry{Kind: storeEndRound, Lobby: lobby.name, Player: playerName, GameIndex: gameIndex})
+	}
+}
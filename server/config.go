@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config is how the server listens, the zero value serves HTTP on port 8080 of every interface
+type Config struct {
+	// Address is the host or IP to bind, every interface when empty
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+
+	// TLSCert and TLSKey are the files of the certificate to serve HTTPS with, HTTP is served without them
+	TLSCert string `json:"tlsCert"`
+	TLSKey  string `json:"tlsKey"`
+
+	// SessionSecret signs session cookies. A random secret is made for every run when it is empty, which signs out
+	// every player when the server restarts.
+	SessionSecret string `json:"sessionSecret"`
+
+	// SecureCookies only sends session cookies over HTTPS, CookieSameSite is lax, strict or none
+	SecureCookies  bool   `json:"secureCookies"`
+	CookieSameSite string `json:"cookieSameSite"`
+
+	// PlayerKeys are the keys of player names only the holder of the key can join as, in any lobby
+	PlayerKeys map[string]string `json:"playerKeys"`
+
+	// AdminKey is the bearer token lobbies are created with, nobody can create them when it is empty
+	AdminKey string `json:"adminKey"`
+}
+
+// DefaultPort is the port served when the config doesn't name one
+const DefaultPort = 8080
+
+// LoadConfig reads a config written as JSON, the fields left out keep their zero values
+func LoadConfig(filename string) (Config, error) {
+	config := Config{}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return config, fmt.Errorf("reading config : %w", err)
+	} else if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing config %v : %w", filename, err)
+	}
+
+	return config, nil
+}
+
+// addr returns the address to listen on
+func (c Config) addr() string {
+	port := c.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	return net.JoinHostPort(c.Address, strconv.Itoa(port))
+}
+
+// sessionSecret returns the secret to sign cookies with
+func (c Config) sessionSecret() ([]byte, error) {
+	if c.SessionSecret != "" {
+		return []byte(c.SessionSecret), nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("making session secret : %w", err)
+	}
+
+	return secret, nil
+}
+
+// sameSite returns the SameSite attribute of session cookies
+func (c Config) sameSite() (http.SameSite, error) {
+	switch strings.ToLower(c.CookieSameSite) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		if !c.SecureCookies {
+			return 0, fmt.Errorf("cookies with SameSite none must be secure")
+		}
+		return http.SameSiteNoneMode, nil
+	}
+
+	return 0, fmt.Errorf("cookie SameSite must be lax, strict or none: %q", c.CookieSameSite)
+}
+
+// Validate returns an error for configs the server can't listen with
+func (c Config) Validate() error {
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("TLS needs both a certificate and a key")
+	} else if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("no port %v", c.Port)
+	}
+
+	_, err := c.sameSite()
+	return err
+}
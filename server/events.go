@@ -0,0 +1,180 @@
+package server
+
+import (
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/types"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// watcherBuffer is how many events a watcher can fall behind before it misses some
+const watcherBuffer = 100
+
+// eventHub sends the events of a lobby to everyone watching it
+type eventHub struct {
+	lock     sync.Mutex
+	watchers map[chan types.GameEvent]struct{}
+
+	// ranks are the last rankings of each game sent, to only send rankings that changed
+	ranks map[int][][]string
+}
+
+func (h *eventHub) watch() chan types.GameEvent {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.watchers == nil {
+		h.watchers = make(map[chan types.GameEvent]struct{})
+	}
+	events := make(chan types.GameEvent, watcherBuffer)
+	h.watchers[events] = struct{}{}
+	return events
+}
+
+func (h *eventHub) stopWatching(events chan types.GameEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.watchers, events)
+}
+
+// publish sends the event to every watcher, watchers that fall behind miss it rather than hold up the game
+func (h *eventHub) publish(event types.GameEvent) {
+	event.Time = time.Now()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for events := range h.watchers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// publishRank sends the rankings of a game if they changed since they were last sent
+func (h *eventHub) publishRank(gameIndex int, rank [][]game.Player) {
+	rankings := make([][]string, len(rank))
+	for i, tied := range rank {
+		for _, player := range tied {
+			rankings[i] = append(rankings[i], player.GetPlayerName())
+		}
+		slices.Sort(rankings[i])
+	}
+
+	h.lock.Lock()
+	if h.ranks == nil {
+		h.ranks = make(map[int][][]string)
+	}
+	changed := !slices.EqualFunc(h.ranks[gameIndex], rankings, slices.Equal[[]string])
+	h.ranks[gameIndex] = rankings
+	h.lock.Unlock()
+
+	if changed {
+		h.publish(types.GameEvent{Kind: types.EventRankChanged, GameIndex: gameIndex, Rankings: rankings})
+	}
+}
+
+// Watch streams the events of a lobby as server-sent events, the game and player query parameters only watch one game
+// or player. The results of other players' questions, their guesses and the rankings are held back until the game is
+// decided for the watcher, so that players can't learn the secret from their rivals. They are then sent, revealed, in
+// the order they happened. Players of the lobby watching with their session see their own moves as they are made.
+func (s *GameServer) Watch(c *gin.Context) {
+	s.lobbiesLock.RLock()
+	lobby := s.lobbies[c.Param("lobby")]
+	s.lobbiesLock.RUnlock()
+	if lobby == nil {
+		c.JSON(404, gin.H{"error": "Lobby not found"})
+		return
+	}
+
+	filter := types.WatchFilter{GameIndex: -1, Player: c.Query("player")}
+	if gameIndex := c.Query("game"); gameIndex != "" {
+		var err error
+		if filter.GameIndex, err = strconv.Atoi(gameIndex); err != nil || filter.GameIndex < 0 || filter.GameIndex >= len(lobby.games) {
+			c.JSON(400, gin.H{"error": "Invalid game index"})
+			return
+		}
+	}
+	viewer := s.watcher(c, lobby)
+
+	events := lobby.events.watch()
+	defer lobby.events.stopWatching(events)
+
+	// hidden are the events of each game held back from the watcher until the game is decided, in the order they happened
+	hidden := make(map[int][]types.GameEvent)
+	send := func(event types.GameEvent) {
+		if filter.Matches(event) {
+			c.SSEvent(event.Kind, event)
+		}
+	}
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			if event.GameIndex == -1 {
+				send(event)
+				return true
+			}
+
+			decided := lobby.decidedFor(event.GameIndex, viewer)
+			if decided && len(hidden[event.GameIndex]) > 0 {
+				for _, hiddenEvent := range hidden[event.GameIndex] {
+					hiddenEvent.Revealed = true
+					send(hiddenEvent)
+				}
+				delete(hidden, event.GameIndex)
+			}
+
+			if !decided && (event.Kind == types.EventRankChanged || event.Player != viewer) {
+				hidden[event.GameIndex] = append(hidden[event.GameIndex], event)
+
+				// Rankings only list players that guessed right, so they aren't sent at all
+				if event.Kind == types.EventRankChanged {
+					return true
+				}
+				event.Result = nil
+				if event.Kind == types.EventGuessMade {
+					event.Code = nil
+				}
+			}
+			send(event)
+			return true
+		}
+	})
+}
+
+// watcher returns the name of the player of the lobby watching its events, by bearer token or session cookie like
+// Authenticate, empty for spectators
+func (s *GameServer) watcher(c *gin.Context, lobby *Lobby) string {
+	var playerName, lobbyName string
+	if token := bearerToken(c); token != "" {
+		owner, _ := s.owner(token)
+		playerName, lobbyName = owner.player, owner.lobby
+	} else {
+		session := sessions.Default(c)
+		playerName, _ = session.Get("playerName").(string)
+
+		// Sessions from before lobbies were added joined the default lobby
+		var ok bool
+		lobbyName, ok = session.Get("lobby").(string)
+		if !ok {
+			lobbyName = DefaultLobby
+		}
+	}
+
+	lobby.playersLock.RLock()
+	defer lobby.playersLock.RUnlock()
+	if _, ok := lobby.players[playerName]; !ok || lobbyName != lobby.name {
+		return ""
+	}
+
+	return playerName
+}
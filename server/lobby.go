@@ -0,0 +1,266 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/caseymerrill/turingsolver/debounce"
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/game_generator"
+	"github.com/caseymerrill/turingsolver/types"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLobby is the name of the lobby of the games the server started with, joined by players that don't name one
+const DefaultLobby = types.DefaultLobby
+
+// maxLobbies limits the lobbies of the server, maxLobbyGames and maxLobbyCards limit the games of a lobby. Generating
+// games takes a while and games with many cards rarely have enough solutions.
+const (
+	maxLobbies    = 100
+	maxLobbyGames = 100
+	maxLobbyCards = 6
+)
+
+// Lobby is a set of games and the players playing them, names only have to be unique within a lobby
+type Lobby struct {
+	name          string
+	games         []game.Game
+	players       map[string]game.Player
+	playersLock   sync.RWMutex
+	printWinCount func()
+	events        eventHub
+
+	// history is what each player has done in each game. A game is decided once every player of the lobby has guessed
+	// it, and stays decided when more players join. Both are guarded by playersLock.
+	history map[string][]playerGame
+	decided []bool
+
+	// movesLock is held from a move in one of the games until it is stored and its events are published, so that the
+	// state file and watchers have the moves in the order the games allowed them. It is taken before playersLock.
+	movesLock sync.Mutex
+}
+
+func newLobby(name string, games []game.Game) *Lobby {
+	return &Lobby{
+		name:    name,
+		games:   games,
+		players: make(map[string]game.Player),
+		history: make(map[string][]playerGame),
+		decided: make([]bool, len(games)),
+		printWinCount: debounce.Debounce(func() {
+			if name != DefaultLobby {
+				fmt.Println("Lobby", name)
+			}
+			game.PrintWinCount(games)
+		}, 1*time.Second),
+	}
+}
+
+func (l *Lobby) response() types.LobbyResponse {
+	l.playersLock.RLock()
+	defer l.playersLock.RUnlock()
+
+	response := types.LobbyResponse{Name: l.name, Games: len(l.games), Players: make([]string, 0, len(l.players))}
+	for playerName := range l.players {
+		response.Players = append(response.Players, playerName)
+	}
+	slices.Sort(response.Players)
+
+	return response
+}
+
+// playerGame is what a player has done in a game, for players that rejoin
+type playerGame struct {
+	answers   []types.Answer
+	roundOpen bool
+	guessed   bool
+}
+
+// addPlayer adds a player that joined the lobby, playersLock must be held
+func (l *Lobby) addPlayer(playerName string) {
+	l.players[playerName] = &types.RemotePlayer{Name: playerName}
+	l.history[playerName] = make([]playerGame, len(l.games))
+}
+
+// played updates what a player has done in a game after a move the game allowed
+func (l *Lobby) played(playerName string, gameIndex int, update func(*playerGame)) {
+	l.playersLock.Lock()
+	defer l.playersLock.Unlock()
+
+	update(&l.history[playerName][gameIndex])
+}
+
+// madeGuess records a guess the game allowed, deciding the game once every player has guessed it
+func (l *Lobby) madeGuess(playerName string, gameIndex int) {
+	l.playersLock.Lock()
+	defer l.playersLock.Unlock()
+
+	played := &l.history[playerName][gameIndex]
+	played.roundOpen, played.guessed = false, true
+	for _, history := range l.history {
+		if !history[gameIndex].guessed {
+			return
+		}
+	}
+	l.decided[gameIndex] = true
+}
+
+// decidedFor returns true if the game's moves can be shown to the viewer without helping anyone play it, once the
+// viewer has guessed it or, for spectators, once the game is decided
+func (l *Lobby) decidedFor(gameIndex int, viewer string) bool {
+	l.playersLock.RLock()
+	defer l.playersLock.RUnlock()
+
+	if viewer != "" {
+		return l.history[viewer][gameIndex].guessed
+	}
+	return l.decided[gameIndex]
+}
+
+func (s *GameServer) CreateLobby(c *gin.Context) {
+	request := types.CreateLobbyRequest{}
+
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if s.adminKey == "" {
+		c.JSON(403, gin.H{"error": "Creating lobbies is turned off, the server has no admin key"})
+		return
+	} else if !s.isAdmin(c) {
+		c.JSON(401, gin.H{"error": "Creating lobbies needs the server's admin key"})
+		return
+	}
+
+	if request.Name == "" {
+		c.JSON(400, gin.H{"error": "Lobby name is required"})
+		return
+	}
+
+	// Checked before generating the games as well as after, so that a full server doesn't generate them for nothing
+	s.lobbiesLock.RLock()
+	full := len(s.lobbies) >= maxLobbies
+	s.lobbiesLock.RUnlock()
+	if full {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("The server already has %v lobbies", maxLobbies)})
+		return
+	}
+
+	games, err := lobbyGames(request)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.lobbiesLock.Lock()
+	defer s.lobbiesLock.Unlock()
+	if _, exists := s.lobbies[request.Name]; exists {
+		c.JSON(400, gin.H{"error": "Lobby already exists"})
+		return
+	} else if len(s.lobbies) >= maxLobbies {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("The server already has %v lobbies", maxLobbies)})
+		return
+	}
+
+	puzzles, err := lobbyPuzzles(games)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	s.persist(storeEntry{Kind: storeLobby, Lobby: request.Name, Puzzles: puzzles})
+
+	if s.recorder != nil {
+		for i := range games {
+			games[i] = s.recorder.Record(games[i])
+		}
+	}
+
+	lobby := newLobby(request.Name, games)
+	s.lobbies[request.Name] = lobby
+
+	c.JSON(200, lobby.response())
+}
+
+// lobbyGames returns the games uploaded to a new lobby, or generates them
+func lobbyGames(request types.CreateLobbyRequest) ([]game.Game, error) {
+	if len(request.Puzzles) > maxLobbyGames {
+		return nil, fmt.Errorf("a lobby can have up to %v puzzles", maxLobbyGames)
+	} else if len(request.Puzzles) > 0 {
+		games := make([]game.Game, len(request.Puzzles))
+		for i, puzzle := range request.Puzzles {
+			puzzleGame, err := game.PuzzleGame(puzzle)
+			if err != nil {
+				return nil, fmt.Errorf("puzzle %v : %w", i+1, err)
+			}
+			games[i] = puzzleGame
+		}
+
+		return games, nil
+	}
+
+	if request.Games < 1 || request.Games > maxLobbyGames {
+		return nil, fmt.Errorf("a lobby needs puzzles or 1 to %v games to generate", maxLobbyGames)
+	}
+
+	nCards := request.NCards
+	if nCards == 0 {
+		nCards = 4
+	} else if nCards < 1 || nCards > maxLobbyCards {
+		return nil, fmt.Errorf("games can have 1 to %v cards", maxLobbyCards)
+	}
+
+	minSolutions := request.MinSolutions
+	if minSolutions == 0 {
+		minSolutions = 2
+	}
+
+	rules := game.DefaultRules
+	if request.Rules != nil {
+		rules = *request.Rules
+	}
+	if rules.QuestionsPerRound < 0 {
+		return nil, fmt.Errorf("questions per round can't be negative, 0 doesn't limit them")
+	}
+
+	return game_generator.GenerateGames(request.Games, nCards, minSolutions, rules), nil
+}
+
+func (s *GameServer) ListLobbies(c *gin.Context) {
+	s.lobbiesLock.RLock()
+	lobbies := make([]*Lobby, 0, len(s.lobbies))
+	for _, lobby := range s.lobbies {
+		lobbies = append(lobbies, lobby)
+	}
+	s.lobbiesLock.RUnlock()
+
+	slices.SortFunc(lobbies, func(a, b *Lobby) int {
+		if a.name < b.name {
+			return -1
+		} else if a.name > b.name {
+			return 1
+		}
+		return 0
+	})
+
+	response := types.ListLobbiesResponse{Lobbies: make([]types.LobbyResponse, len(lobbies))}
+	for i, lobby := range lobbies {
+		response.Lobbies[i] = lobby.response()
+	}
+
+	c.JSON(200, response)
+}
+
+// isAdmin returns true if the request has the admin key as its bearer token, never when the server has no admin key
+func (s *GameServer) isAdmin(c *gin.Context) bool {
+	return s.adminKey != "" && subtle.ConstantTimeCompare([]byte(s.adminKey), []byte(bearerToken(c))) == 1
+}
+
+// currentLobby returns the lobby of the player Authenticate found
+func currentLobby(c *gin.Context) *Lobby {
+	return c.MustGet("lobby").(*Lobby)
+}
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/caseymerrill/turingsolver/debounce"
 	"github.com/caseymerrill/turingsolver/game"
 	"github.com/caseymerrill/turingsolver/types"
 	"github.com/gin-contrib/sessions"
@@ -14,17 +17,28 @@ import (
 )
 
 type GameServer struct {
-	games         []game.Game
-	players       map[string]game.Player
-	playersLock   sync.RWMutex
-	printWinCount func()
+	lobbies     map[string]*Lobby
+	lobbiesLock sync.RWMutex
+
+	// adminKey is the bearer token lobbies are created with, nobody can create them when it is empty
+	adminKey string
+
+	// store keeps the state across restarts and recorder logs the moves of every lobby's games, when they're set
+	store    *store
+	recorder *game.Recorder
+
+	// tokens are the hashes of the bearer tokens given to players that joined, keys are the keys registered for player
+	// names in the config
+	tokens     map[string]tokenOwner
+	tokensLock sync.RWMutex
+	keys       map[string]string
 }
 
 func (s *GameServer) Join(c *gin.Context) {
 	request := types.JoinRequest{}
 
 	if err := c.BindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": err.Error})
+		c.JSON(400, gin.H{"error": err.Error()})
 		fmt.Println(err)
 		return
 	}
@@ -34,32 +48,89 @@ func (s *GameServer) Join(c *gin.Context) {
 		return
 	}
 
-	s.playersLock.Lock()
-	defer s.playersLock.Unlock()
-	if _, exists := s.players[request.PlayerName]; exists {
-		c.JSON(400, gin.H{"error": "Player already exists"})
+	if request.Lobby == "" {
+		request.Lobby = DefaultLobby
+	}
+
+	s.lobbiesLock.RLock()
+	lobby := s.lobbies[request.Lobby]
+	s.lobbiesLock.RUnlock()
+	if lobby == nil {
+		c.JSON(404, gin.H{"error": "Lobby not found"})
 		return
 	}
 
-	s.players[request.PlayerName] = &types.RemotePlayer{Name: request.PlayerName}
+	key := request.Key
+	if key == "" {
+		key = bearerToken(c)
+	}
+	if s.wrongKey(request.PlayerName, key) {
+		c.JSON(401, gin.H{"error": "Player name is registered, join with its key"})
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	lobby.movesLock.Lock()
+	defer lobby.movesLock.Unlock()
+	lobby.playersLock.Lock()
+	defer lobby.playersLock.Unlock()
+	_, rejoining := lobby.players[request.PlayerName]
+	if rejoining && !s.mayRejoin(lobby, request.PlayerName, key) {
+		c.JSON(400, gin.H{"error": "Player already exists"})
+		return
+	}
 
 	session := sessions.Default(c)
 	session.Set("playerName", request.PlayerName)
+	session.Set("lobby", request.Lobby)
 	if err := session.Save(); err != nil {
-		delete(s.players, request.PlayerName)
 		c.JSON(500, gin.H{"error": "Failed to save session"})
+		return
+	}
+
+	if rejoining {
+		s.endOpenRounds(lobby, request.PlayerName)
+	} else {
+		lobby.addPlayer(request.PlayerName)
 	}
 
-	c.JSON(200, gin.H{"playerName": request.PlayerName})
+	// Rejoining gives the player a new token, the old one may have been lost with the player
+	tokenHash := hashToken(token)
+	s.setToken(tokenHash, tokenOwner{lobby: lobby.name, player: request.PlayerName})
+	s.persist(storeEntry{Kind: storeJoin, Lobby: lobby.name, Player: request.PlayerName, TokenHash: tokenHash})
+	if !rejoining {
+		lobby.events.publish(types.GameEvent{Kind: types.EventPlayerJoined, GameIndex: -1, Player: request.PlayerName})
+	}
+	c.JSON(200, types.JoinResponse{PlayerName: request.PlayerName, Lobby: request.Lobby, Token: token})
 }
 
 func (s *GameServer) GetGames(c *gin.Context) {
+	lobby := currentLobby(c)
+	games := lobby.games
 	response := types.GetGamesResponse{
-		Games: make([][]int, len(s.games)),
+		Games:   make([][]int, len(games)),
+		Rules:   make([]types.Rules, len(games)),
+		Answers: make([][]types.Answer, len(games)),
+		Guessed: make([]bool, len(games)),
+	}
+
+	player, _ := c.Get("player")
+	lobby.playersLock.RLock()
+	for gameIndex, played := range lobby.history[player.(*types.RemotePlayer).Name] {
+		response.Answers[gameIndex] = slices.Clone(played.answers)
+		response.Guessed[gameIndex] = played.guessed
 	}
+	lobby.playersLock.RUnlock()
 
-	for gameIndex := range s.games {
-		cards := s.games[gameIndex].GetVerifierCards()
+	for gameIndex := range games {
+		response.Rules[gameIndex] = games[gameIndex].Rules()
+
+		cards := games[gameIndex].GetVerifierCards()
 		response.Games[gameIndex] = make([]int, len(cards))
 		for cardIndex, card := range cards {
 			response.Games[gameIndex][cardIndex] = card.CardNumber
@@ -69,21 +140,49 @@ func (s *GameServer) GetGames(c *gin.Context) {
 	c.JSON(200, response)
 }
 
-func (s *GameServer) AskQuestion(c *gin.Context) {
-	request := types.AskQuestionRequest{}
+func (s *GameServer) StartRound(c *gin.Context) {
+	request := types.StartRoundRequest{}
 
+	games := currentLobby(c).games
 	if err := c.BindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": err.Error})
+		c.JSON(400, gin.H{"error": err.Error()})
 		fmt.Println(err)
 		return
-	} else if request.GameIndex < 0 || request.GameIndex >= len(s.games) {
+	} else if request.GameIndex < 0 || request.GameIndex >= len(games) {
 		c.JSON(400, gin.H{"error": "Invalid game index"})
 		return
 	}
 
-	currentGame := s.games[request.GameIndex]
-	if request.VerifierIndex < 0 || request.VerifierIndex >= len(currentGame.GetVerifierCards()) {
-		c.JSON(400, gin.H{"error": "Invalid verifier index"})
+	player, ok := c.Get("player")
+	if !ok {
+		c.JSON(500, gin.H{"error": "Player not found"})
+		return
+	}
+
+	lobby := currentLobby(c)
+	lobby.movesLock.Lock()
+	defer lobby.movesLock.Unlock()
+
+	if err := games[request.GameIndex].StartRound(player.(*types.RemotePlayer), request.Code); err != nil {
+		gameError(c, err)
+		return
+	}
+	s.persist(storeEntry{Kind: storeStartRound, Lobby: lobby.name, Player: player.(*types.RemotePlayer).Name, GameIndex: request.GameIndex, Code: request.Code})
+	lobby.played(player.(*types.RemotePlayer).Name, request.GameIndex, func(played *playerGame) { played.roundOpen = true })
+
+	c.JSON(200, types.BinaryResponse{Result: true})
+}
+
+func (s *GameServer) EndRound(c *gin.Context) {
+	request := types.EndRoundRequest{}
+
+	games := currentLobby(c).games
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		fmt.Println(err)
+		return
+	} else if request.GameIndex < 0 || request.GameIndex >= len(games) {
+		c.JSON(400, gin.H{"error": "Invalid game index"})
 		return
 	}
 
@@ -93,18 +192,75 @@ func (s *GameServer) AskQuestion(c *gin.Context) {
 		return
 	}
 
-	check := currentGame.AskQuestion(player.(*types.RemotePlayer), request.Code, request.VerifierIndex)
+	lobby := currentLobby(c)
+	lobby.movesLock.Lock()
+	defer lobby.movesLock.Unlock()
+
+	if err := games[request.GameIndex].EndRound(player.(*types.RemotePlayer)); err != nil {
+		gameError(c, err)
+		return
+	}
+	s.persist(storeEntry{Kind: storeEndRound, Lobby: lobby.name, Player: player.(*types.RemotePlayer).Name, GameIndex: request.GameIndex})
+	lobby.played(player.(*types.RemotePlayer).Name, request.GameIndex, func(played *playerGame) { played.roundOpen = false })
+
+	c.JSON(200, types.BinaryResponse{Result: true})
+}
+
+func (s *GameServer) AskQuestion(c *gin.Context) {
+	request := types.AskQuestionRequest{}
+
+	games := currentLobby(c).games
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		fmt.Println(err)
+		return
+	} else if request.GameIndex < 0 || request.GameIndex >= len(games) {
+		c.JSON(400, gin.H{"error": "Invalid game index"})
+		return
+	}
+
+	player, ok := c.Get("player")
+	if !ok {
+		c.JSON(500, gin.H{"error": "Player not found"})
+		return
+	}
+
+	lobby := currentLobby(c)
+	lobby.movesLock.Lock()
+	defer lobby.movesLock.Unlock()
+
+	currentGame := games[request.GameIndex]
+	check, err := currentGame.AskQuestion(player.(*types.RemotePlayer), request.Code, request.VerifierIndex)
+	if err != nil {
+		gameError(c, err)
+		return
+	}
+
+	s.persist(storeEntry{Kind: storeQuestion, Lobby: lobby.name, Player: player.(*types.RemotePlayer).Name, GameIndex: request.GameIndex, Code: request.Code, VerifierIndex: request.VerifierIndex})
+	lobby.played(player.(*types.RemotePlayer).Name, request.GameIndex, func(played *playerGame) {
+		played.answers = append(played.answers, types.Answer{Code: request.Code, VerifierIndex: request.VerifierIndex, Result: check})
+	})
+	lobby.events.publish(types.GameEvent{
+		Kind:          types.EventQuestionAsked,
+		GameIndex:     request.GameIndex,
+		Player:        player.(*types.RemotePlayer).Name,
+		Code:          request.Code,
+		VerifierIndex: request.VerifierIndex,
+		Result:        &check,
+	})
+
 	c.JSON(200, types.BinaryResponse{Result: check})
 }
 
 func (s *GameServer) MakeGuess(c *gin.Context) {
 	request := types.MakeGuessRequest{}
 
+	games := currentLobby(c).games
 	if err := c.BindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": err.Error})
+		c.JSON(400, gin.H{"error": err.Error()})
 		fmt.Println(err)
 		return
-	} else if request.GameIndex < 0 || request.GameIndex >= len(s.games) {
+	} else if request.GameIndex < 0 || request.GameIndex >= len(games) {
 		c.JSON(400, gin.H{"error": "Invalid game index"})
 		return
 	}
@@ -115,26 +271,56 @@ func (s *GameServer) MakeGuess(c *gin.Context) {
 		return
 	}
 
-	currentGame := s.games[request.GameIndex]
-	result := currentGame.MakeGuess(player.(*types.RemotePlayer), request.Code)
+	lobby := currentLobby(c)
+	lobby.movesLock.Lock()
+	defer lobby.movesLock.Unlock()
 
-	s.printWinCount()
+	currentGame := games[request.GameIndex]
+	result, err := currentGame.MakeGuess(player.(*types.RemotePlayer), request.Code)
+	if err != nil {
+		gameError(c, err)
+		return
+	}
+
+	lobby.madeGuess(player.(*types.RemotePlayer).Name, request.GameIndex)
+	s.persist(storeEntry{Kind: storeGuess, Lobby: lobby.name, Player: player.(*types.RemotePlayer).Name, GameIndex: request.GameIndex, Code: request.Code})
+	lobby.events.publish(types.GameEvent{
+		Kind:      types.EventGuessMade,
+		GameIndex: request.GameIndex,
+		Player:    player.(*types.RemotePlayer).Name,
+		Code:      request.Code,
+		Result:    &result,
+	})
+	lobby.events.publishRank(request.GameIndex, currentGame.Rank())
+	lobby.printWinCount()
 
 	c.JSON(200, types.BinaryResponse{Result: result})
 }
 
+// gameError responds with an error returned by a game, moves the game doesn't allow are the player's fault
+func gameError(c *gin.Context, err error) {
+	code := game.ErrorCode(err)
+	if code == "" {
+		c.JSON(500, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(400, types.ErrorResponse{Error: err.Error(), Code: code})
+}
+
 func (s *GameServer) GetRank(c *gin.Context) {
 	request := types.RankRequest{}
 
+	games := currentLobby(c).games
 	if err := c.BindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": err.Error})
+		c.JSON(400, gin.H{"error": err.Error()})
 		fmt.Println(err)
-	} else if request.GameIndex < 0 || request.GameIndex >= len(s.games) {
+	} else if request.GameIndex < 0 || request.GameIndex >= len(games) {
 		c.JSON(400, gin.H{"error": "Invalid game index"})
 		return
 	}
 
-	currentGame := s.games[request.GameIndex]
+	currentGame := games[request.GameIndex]
 	rankings := currentGame.Rank()
 
 	// Convert to remote players
@@ -149,54 +335,174 @@ func (s *GameServer) GetRank(c *gin.Context) {
 	c.JSON(200, types.RankResponse{Rankings: remoteRankings})
 }
 
+// Authenticate finds the player of a request by its bearer token, or by its session cookie if it has no token
 func (s *GameServer) Authenticate(c *gin.Context) {
-	session := sessions.Default(c)
-	playerName := session.Get("playerName")
-	s.playersLock.RLock()
-	defer s.playersLock.RUnlock()
-	playerNameStr, ok := playerName.(string)
-	if !ok {
-		c.JSON(401, gin.H{"error": "Not authenticated, no player name set."})
+	var playerNameStr, lobbyName string
+	if token := bearerToken(c); token != "" {
+		owner, ok := s.owner(token)
+		if !ok {
+			c.JSON(401, gin.H{"error": "Not authenticated, unknown token."})
+			c.Abort()
+			return
+		}
+		playerNameStr, lobbyName = owner.player, owner.lobby
+	} else {
+		session := sessions.Default(c)
+		playerName := session.Get("playerName")
+		var ok bool
+		playerNameStr, ok = playerName.(string)
+		if !ok {
+			c.JSON(401, gin.H{"error": "Not authenticated, no player name set."})
+			c.Abort()
+			return
+		}
+
+		// Sessions from before lobbies were added joined the default lobby
+		lobbyName, ok = session.Get("lobby").(string)
+		if !ok {
+			lobbyName = DefaultLobby
+		}
+	}
+
+	s.lobbiesLock.RLock()
+	lobby := s.lobbies[lobbyName]
+	s.lobbiesLock.RUnlock()
+	if lobby == nil {
+		c.JSON(401, gin.H{"error": "Not authenticated, lobby not found."})
 		c.Abort()
 		return
 	}
 
-	if player := s.players[playerNameStr]; player == nil {
+	lobby.playersLock.RLock()
+	player := lobby.players[playerNameStr]
+	lobby.playersLock.RUnlock()
+	if player == nil {
 		c.JSON(401, gin.H{"error": "Not authenticated, player not fount."})
 		c.Abort()
 		return
-	} else {
-		c.Set("player", player)
 	}
 
+	c.Set("player", player)
+	c.Set("lobby", lobby)
+
 	c.Next()
 }
 
-func (s *GameServer) Listen() {
+// shutdownTimeout is how long requests in progress have to finish once the server is stopped
+const shutdownTimeout = 5 * time.Second
+
+// Listen serves the games until ctx is done, then finishes the requests in progress and prints the final rankings
+func (s *GameServer) Listen(ctx context.Context, config Config) error {
+	r, err := s.router(config)
+	if err != nil {
+		return err
+	}
+
+	// Requests get ctx so that event streams end when the server stops
+	httpServer := &http.Server{
+		Addr:        config.addr(),
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if config.TLSCert != "" {
+			served <- httpServer.ListenAndServeTLS(config.TLSCert, config.TLSKey)
+		} else {
+			served <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("running server : %w", err)
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Stopping Server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	shutdownErr := httpServer.Shutdown(shutdownCtx)
+
+	s.finish()
+	if shutdownErr != nil {
+		return fmt.Errorf("stopping server : %w", shutdownErr)
+	}
+	return nil
+}
+
+// finish prints the final rankings of every lobby and closes the state file
+func (s *GameServer) finish() {
+	s.lobbiesLock.Lock()
+	defer s.lobbiesLock.Unlock()
+
+	names := make([]string, 0, len(s.lobbies))
+	for name := range s.lobbies {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Println("Lobby", name)
+		game.PrintWinCount(s.lobbies[name].games)
+	}
+
+	if s.store != nil {
+		s.store.close()
+	}
+}
+
+// router routes the requests of players to the server, with the sessions config sets up
+func (s *GameServer) router(config Config) (*gin.Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret, err := config.sessionSecret()
+	if err != nil {
+		return nil, err
+	}
+	sameSite, _ := config.sameSite()
+	s.keys = config.PlayerKeys
+
 	r := gin.Default()
-	store := cookie.NewStore([]byte("super-secret-turing-game-cookie-key"))
+	store := cookie.NewStore(secret)
+	store.Options(sessions.Options{Path: "/", MaxAge: 30 * 24 * 60 * 60, HttpOnly: true, Secure: config.SecureCookies, SameSite: sameSite})
 	r.Use(sessions.Sessions("session", store))
 
 	r.POST("/join", s.Join)
+	r.GET("/lobbies", s.ListLobbies)
+	r.POST("/lobbies", s.CreateLobby)
+	r.GET("/lobbies/:lobby/events", s.Watch)
 
 	authenticatedGroup := r.Group("/player", s.Authenticate)
 	authenticatedGroup.GET("/games", s.GetGames)
+	authenticatedGroup.POST("/start-round", s.StartRound)
 	authenticatedGroup.POST("/test-verifier", s.AskQuestion)
+	authenticatedGroup.POST("/end-round", s.EndRound)
 	authenticatedGroup.POST("/make-guess", s.MakeGuess)
 	authenticatedGroup.GET("/rank", s.GetRank)
 
-	if err := r.Run(); err != nil {
-		fmt.Println("Running server : ", err)
+	return r, nil
+}
+
+// Record logs every move made in the games of every lobby, including lobbies created later. Record must be called
+// before Listen, and after Persist.
+func (s *GameServer) Record(recorder *game.Recorder) {
+	s.recorder = recorder
+	for _, lobby := range s.lobbies {
+		for i := range lobby.games {
+			lobby.games[i] = recorder.Record(lobby.games[i])
+		}
 	}
 }
 
-func NewGameServer(games []game.Game) *GameServer {
+// NewGameServer serves the games in the default lobby, more lobbies can only be created with adminKey
+func NewGameServer(games []game.Game, adminKey string) *GameServer {
 	return &GameServer{
-		games:       games,
-		players:     make(map[string]game.Player),
-		playersLock: sync.RWMutex{},
-		printWinCount: debounce.Debounce(func() {
-			game.PrintWinCount(games)
-		}, 1*time.Second),
+		lobbies:  map[string]*Lobby{DefaultLobby: newLobby(DefaultLobby, games)},
+		adminKey: adminKey,
+		tokens:   make(map[string]tokenOwner),
 	}
 }
@@ -0,0 +1,582 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/game_generator"
+	"github.com/caseymerrill/turingsolver/types"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+func testGames(n int) []game.Game {
+	return game_generator.GenerateGames(n, 4, 2, game.DefaultRules)
+}
+
+func testRouter(t *testing.T, s *GameServer, config Config) http.Handler {
+	t.Helper()
+	r, err := s.router(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+// call sends a request with token as its bearer token, when it isn't empty, and decodes the response into response,
+// when it isn't nil. It returns the status of the response.
+func call(t *testing.T, r http.Handler, method string, path string, token string, body any, response any) int {
+	t.Helper()
+	var requestBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requestBody = bytes.NewReader(data)
+	}
+
+	request := httptest.NewRequest(method, path, requestBody)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, request)
+
+	if response != nil && recorder.Code == 200 {
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Fatalf("%v %v: %v", method, path, err)
+		}
+	}
+	return recorder.Code
+}
+
+// join joins as the player and returns its token, failing the test unless the server responds with want
+func join(t *testing.T, r http.Handler, request types.JoinRequest, want int) string {
+	t.Helper()
+	joined := types.JoinResponse{}
+	if status := call(t, r, "POST", "/join", "", request, &joined); status != want {
+		t.Fatalf("joining as %+v: status %v, expected %v", request, status, want)
+	} else if want == 200 && joined.Token == "" {
+		t.Fatalf("joining as %+v gave no token", request)
+	}
+
+	return joined.Token
+}
+
+func TestJoinGivesToken(t *testing.T) {
+	r := testRouter(t, NewGameServer(testGames(2), ""), Config{})
+	token := join(t, r, types.JoinRequest{PlayerName: "alice"}, 200)
+
+	games := types.GetGamesResponse{}
+	if status := call(t, r, "GET", "/player/games", token, nil, &games); status != 200 || len(games.Games) != 2 {
+		t.Fatalf("expected 2 games with the token: status %v %+v", status, games)
+	}
+	if status := call(t, r, "GET", "/player/games", "unknown", nil, nil); status != 401 {
+		t.Fatalf("expected an unknown token to be refused: status %v", status)
+	}
+	if status := call(t, r, "GET", "/player/games", "", nil, nil); status != 401 {
+		t.Fatalf("expected a request without a token or session to be refused: status %v", status)
+	}
+}
+
+func TestRegisteredKeys(t *testing.T) {
+	r := testRouter(t, NewGameServer(testGames(1), ""), Config{PlayerKeys: map[string]string{"bot": "secret"}})
+
+	join(t, r, types.JoinRequest{PlayerName: "bot"}, 401)
+	join(t, r, types.JoinRequest{PlayerName: "bot", Key: "wrong"}, 401)
+	join(t, r, types.JoinRequest{PlayerName: "bot", Key: "secret"}, 200)
+
+	// The key rejoins the player, sent in the request or as its bearer token
+	join(t, r, types.JoinRequest{PlayerName: "bot", Key: "secret"}, 200)
+	joined := types.JoinResponse{}
+	if status := call(t, r, "POST", "/join", "secret", types.JoinRequest{PlayerName: "bot"}, &joined); status != 200 || joined.Token == "" {
+		t.Fatalf("expected the key as a bearer token to rejoin: status %v %+v", status, joined)
+	}
+
+	join(t, r, types.JoinRequest{PlayerName: "alice", Key: "anything"}, 200)
+}
+
+func TestRejoinRotatesToken(t *testing.T) {
+	r := testRouter(t, NewGameServer(testGames(1), ""), Config{})
+	first := join(t, r, types.JoinRequest{PlayerName: "alice"}, 200)
+	join(t, r, types.JoinRequest{PlayerName: "alice"}, 400)
+
+	second := join(t, r, types.JoinRequest{PlayerName: "alice", Key: first}, 200)
+	if second == first {
+		t.Fatal("expected a new token on rejoining")
+	}
+	if status := call(t, r, "GET", "/player/games", first, nil, nil); status != 401 {
+		t.Fatalf("expected the token before rejoining to be revoked: status %v", status)
+	}
+	if status := call(t, r, "GET", "/player/games", second, nil, nil); status != 200 {
+		t.Fatalf("expected the new token to work: status %v", status)
+	}
+	join(t, r, types.JoinRequest{PlayerName: "alice", Key: first}, 400)
+}
+
+func TestTokenOnlyRejoinsItsLobby(t *testing.T) {
+	s := NewGameServer(testGames(2), "")
+	s.lobbies["other"] = newLobby("other", testGames(1))
+	r := testRouter(t, s, Config{})
+
+	defaultToken := join(t, r, types.JoinRequest{PlayerName: "alice"}, 200)
+	otherToken := join(t, r, types.JoinRequest{PlayerName: "alice", Lobby: "other"}, 200)
+	join(t, r, types.JoinRequest{PlayerName: "alice", Lobby: "other", Key: defaultToken}, 400)
+
+	for token, want := range map[string]int{defaultToken: 2, otherToken: 1} {
+		games := types.GetGamesResponse{}
+		if status := call(t, r, "GET", "/player/games", token, nil, &games); status != 200 || len(games.Games) != want {
+			t.Fatalf("expected the token to play the %v games of its lobby: status %v %+v", want, status, games)
+		}
+	}
+}
+
+func TestRejoinEndsOpenRounds(t *testing.T) {
+	r := testRouter(t, NewGameServer(testGames(1), ""), Config{})
+	token := join(t, r, types.JoinRequest{PlayerName: "alice"}, 200)
+	if status := call(t, r, "POST", "/player/start-round", token, types.StartRoundRequest{Code: []int{1, 2, 3}}, nil); status != 200 {
+		t.Fatalf("starting round: status %v", status)
+	} else if status := call(t, r, "POST", "/player/test-verifier", token, types.AskQuestionRequest{Code: []int{1, 2, 3}}, nil); status != 200 {
+		t.Fatalf("asking question: status %v", status)
+	}
+
+	token = join(t, r, types.JoinRequest{PlayerName: "alice", Key: token}, 200)
+	if status := call(t, r, "POST", "/player/start-round", token, types.StartRoundRequest{Code: []int{1, 2, 3}}, nil); status != 200 {
+		t.Fatalf("expected rejoining to end the open round: status %v", status)
+	}
+
+	games := types.GetGamesResponse{}
+	if status := call(t, r, "GET", "/player/games", token, nil, &games); status != 200 {
+		t.Fatalf("getting games: status %v", status)
+	} else if len(games.Answers[0]) != 1 || games.Guessed[0] {
+		t.Fatalf("expected the answer before rejoining and no guess: %+v", games)
+	}
+}
+
+func TestCreateLobbyNeedsAdminKey(t *testing.T) {
+	request := types.CreateLobbyRequest{Name: "other", Games: 1}
+	r := testRouter(t, NewGameServer(testGames(1), ""), Config{})
+	if status := call(t, r, "POST", "/lobbies", "", request, nil); status != 403 {
+		t.Fatalf("expected lobbies to be turned off without an admin key: status %v", status)
+	}
+
+	r = testRouter(t, NewGameServer(testGames(1), "admin"), Config{})
+	if status := call(t, r, "POST", "/lobbies", "", request, nil); status != 401 {
+		t.Fatalf("expected a request without the admin key to be refused: status %v", status)
+	} else if status := call(t, r, "POST", "/lobbies", "wrong", request, nil); status != 401 {
+		t.Fatalf("expected the wrong admin key to be refused: status %v", status)
+	}
+
+	lobby := types.LobbyResponse{}
+	if status := call(t, r, "POST", "/lobbies", "admin", request, &lobby); status != 200 || lobby.Games != 1 {
+		t.Fatalf("expected the admin key to create the lobby: status %v %+v", status, lobby)
+	} else if status := call(t, r, "POST", "/lobbies", "admin", request, nil); status != 400 {
+		t.Fatalf("expected a lobby of the same name to be refused: status %v", status)
+	}
+
+	if status := call(t, r, "POST", "/join", "", types.JoinRequest{PlayerName: "alice", Lobby: "other"}, nil); status != 200 {
+		t.Fatalf("joining the new lobby: status %v", status)
+	}
+	lobbies := types.ListLobbiesResponse{}
+	if status := call(t, r, "GET", "/lobbies", "", nil, &lobbies); status != 200 || len(lobbies.Lobbies) != 2 {
+		t.Fatalf("expected the default and the new lobby: status %v %+v", status, lobbies)
+	} else if other := lobbies.Lobbies[1]; other.Name != "other" || len(other.Players) != 1 || other.Players[0] != "alice" {
+		t.Fatalf("expected alice in the new lobby: %+v", other)
+	}
+}
+
+func TestCreateLobbyLimits(t *testing.T) {
+	negativeRules := game.Rules{QuestionsPerRound: -1}
+	for name, request := range map[string]types.CreateLobbyRequest{
+		"no name":            {Games: 1},
+		"no games":           {Name: "other"},
+		"too many games":     {Name: "other", Games: maxLobbyGames + 1},
+		"too many cards":     {Name: "other", Games: 1, NCards: maxLobbyCards + 1},
+		"too many puzzles":   {Name: "other", Puzzles: make([]types.Puzzle, maxLobbyGames+1)},
+		"negative questions": {Name: "other", Games: 1, Rules: &negativeRules},
+	} {
+		r := testRouter(t, NewGameServer(nil, "admin"), Config{})
+		if status := call(t, r, "POST", "/lobbies", "admin", request, nil); status != 400 {
+			t.Fatalf("%v: expected the lobby to be refused: status %v", name, status)
+		}
+	}
+
+	s := NewGameServer(nil, "admin")
+	for len(s.lobbies) < maxLobbies {
+		name := fmt.Sprint("lobby", len(s.lobbies))
+		s.lobbies[name] = newLobby(name, nil)
+	}
+	if status := call(t, testRouter(t, s, Config{}), "POST", "/lobbies", "admin", types.CreateLobbyRequest{Name: "other", Games: 1}, nil); status != 400 {
+		t.Fatalf("expected a full server to refuse the lobby: status %v", status)
+	}
+}
+
+// watchEvents watches the lobby as the player of token, or as a spectator when it is empty, until the test ends,
+// returning the events received
+func watchEvents(t *testing.T, s *GameServer, addr string, lobby string, token string) <-chan types.GameEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan types.GameEvent, watcherBuffer)
+	go game.WatchLobby(ctx, addr, lobby, token, types.WatchFilter{GameIndex: -1}, func(event types.GameEvent) {
+		events <- event
+	})
+
+	// Moves made before the watcher is listening wouldn't reach it
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		s.lobbies[lobby].events.lock.Lock()
+		watching := len(s.lobbies[lobby].events.watchers) > 0
+		s.lobbies[lobby].events.lock.Unlock()
+		if watching {
+			return events
+		} else if time.Now().After(deadline) {
+			t.Fatal("watcher never connected")
+		}
+	}
+}
+
+// expectEvent fails the test unless the next event is of the kind and player and has a result exactly when visible
+func expectEvent(t *testing.T, events <-chan types.GameEvent, kind string, player string, visible bool, revealed bool) {
+	t.Helper()
+	select {
+	case event := <-events:
+		hasResult := event.Result != nil || event.Rankings != nil
+		if event.Kind != kind || event.Player != player || hasResult != visible || event.Revealed != revealed {
+			t.Fatalf("expected %v by %q, visible %v and revealed %v, got %+v", kind, player, visible, revealed, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected %v by %q", kind, player)
+	}
+}
+
+// joinGame joins the only game of the server's default lobby as the player
+func joinGame(t *testing.T, addr string, playerName string) (game.Game, game.Player) {
+	t.Helper()
+	games, err := game.JoinGames(addr, playerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return games[0], &types.RemotePlayer{Name: playerName}
+}
+
+func TestWatchHidesMovesUntilDecided(t *testing.T) {
+	games := testGames(1)
+	secret := games[0].(*game.AutoGame).Solution().Code
+	wrong := []int{secret[0]%5 + 1, secret[1], secret[2]}
+
+	s := NewGameServer(games, "")
+	httpServer := httptest.NewServer(testRouter(t, s, Config{}))
+	t.Cleanup(httpServer.Close)
+	events := watchEvents(t, s, httpServer.URL, DefaultLobby, "")
+
+	alicesGame, alice := joinGame(t, httpServer.URL, "alice")
+	bobsGame, bob := joinGame(t, httpServer.URL, "bob")
+	expectEvent(t, events, types.EventPlayerJoined, "alice", false, false)
+	expectEvent(t, events, types.EventPlayerJoined, "bob", false, false)
+
+	if err := alicesGame.StartRound(alice, secret); err != nil {
+		t.Fatal(err)
+	} else if _, err := alicesGame.AskQuestion(alice, secret, 0); err != nil {
+		t.Fatal(err)
+	} else if err := alicesGame.EndRound(alice); err != nil {
+		t.Fatal(err)
+	} else if _, err := alicesGame.MakeGuess(alice, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	// The ranking would show that alice guessed right, so it isn't sent until bob guesses too
+	expectEvent(t, events, types.EventQuestionAsked, "alice", false, false)
+	expectEvent(t, events, types.EventGuessMade, "alice", false, false)
+
+	if _, err := bobsGame.MakeGuess(bob, wrong); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, types.EventQuestionAsked, "alice", true, true)
+	expectEvent(t, events, types.EventGuessMade, "alice", true, true)
+	expectEvent(t, events, types.EventRankChanged, "", true, true)
+	expectEvent(t, events, types.EventGuessMade, "bob", true, false)
+
+	// Players joining later don't hide a decided game again
+	carolsGame, carol := joinGame(t, httpServer.URL, "carol")
+	if err := carolsGame.StartRound(carol, secret); err != nil {
+		t.Fatal(err)
+	} else if _, err := carolsGame.AskQuestion(carol, secret, 0); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, types.EventPlayerJoined, "carol", false, false)
+	expectEvent(t, events, types.EventQuestionAsked, "carol", true, false)
+}
+
+func TestWatchShowsPlayersTheirGuessedGames(t *testing.T) {
+	games := testGames(1)
+	secret := games[0].(*game.AutoGame).Solution().Code
+
+	s := NewGameServer(games, "")
+	httpServer := httptest.NewServer(testRouter(t, s, Config{}))
+	t.Cleanup(httpServer.Close)
+
+	alicesGames, token, err := game.Join(httpServer.URL, types.JoinRequest{PlayerName: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alicesGame, alice := alicesGames[0], &types.RemotePlayer{Name: "alice"}
+	bobsGame, bob := joinGame(t, httpServer.URL, "bob")
+	events := watchEvents(t, s, httpServer.URL, DefaultLobby, token)
+
+	for _, move := range []struct {
+		game   game.Game
+		player game.Player
+	}{{bobsGame, bob}, {alicesGame, alice}} {
+		if err := move.game.StartRound(move.player, secret); err != nil {
+			t.Fatal(err)
+		} else if _, err := move.game.AskQuestion(move.player, secret, 0); err != nil {
+			t.Fatal(err)
+		} else if err := move.game.EndRound(move.player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectEvent(t, events, types.EventQuestionAsked, "bob", false, false)
+	expectEvent(t, events, types.EventQuestionAsked, "alice", true, false)
+
+	// Guessing decides the game for alice, bob hasn't guessed it yet
+	if _, err := alicesGame.MakeGuess(alice, secret); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, events, types.EventQuestionAsked, "bob", true, true)
+	expectEvent(t, events, types.EventGuessMade, "alice", true, false)
+	expectEvent(t, events, types.EventRankChanged, "", true, false)
+}
+
+// send sends a request with the session of the client and returns the status of the response
+func send(t *testing.T, client *http.Client, method string, url string, body any) int {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestStoreRestoresPlayers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.jsonl")
+	s := NewGameServer(testGames(1), "")
+	if err := s.Persist(filename); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{SessionSecret: "secret"}
+	httpServer := httptest.NewServer(testRouter(t, s, config))
+
+	// Cookies don't depend on the port, so the session is sent to the restarted server too
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+	if status := send(t, client, "POST", httpServer.URL+"/join", types.JoinRequest{PlayerName: "alice"}); status != 200 {
+		t.Fatalf("joining: status %v", status)
+	} else if status := send(t, client, "POST", httpServer.URL+"/player/start-round", types.StartRoundRequest{Code: []int{1, 2, 3}}); status != 200 {
+		t.Fatalf("starting round: status %v", status)
+	} else if status := send(t, client, "POST", httpServer.URL+"/player/test-verifier", types.AskQuestionRequest{Code: []int{1, 2, 3}}); status != 200 {
+		t.Fatalf("asking question: status %v", status)
+	}
+	httpServer.Close()
+	s.store.close()
+
+	if info, err := os.Stat(filename); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected only the owner to read the state, mode %v", info.Mode().Perm())
+	}
+
+	restored := NewGameServer(testGames(1), "")
+	if err := restored.Persist(filename); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.store.close()
+	httpServer = httptest.NewServer(testRouter(t, restored, config))
+	defer httpServer.Close()
+
+	original, restoredGame := s.lobbies[DefaultLobby].games[0], restored.lobbies[DefaultLobby].games[0]
+	if !reflect.DeepEqual(game.NewPuzzle(original.(*game.AutoGame)), game.NewPuzzle(restoredGame.(*game.AutoGame))) {
+		t.Fatal("expected the games of the state instead of the new ones")
+	} else if moves := restoredGame.(*game.AutoGame).Stats()[restored.lobbies[DefaultLobby].players["alice"]]; moves == nil || moves.QuestionsAsked() != 1 {
+		t.Fatalf("expected the question before the restart: %+v", moves)
+	}
+
+	if status := send(t, client, "POST", httpServer.URL+"/player/start-round", types.StartRoundRequest{Code: []int{2, 2, 2}}); status != 400 {
+		t.Fatalf("expected the round to still be open: status %v", status)
+	} else if status := send(t, client, "POST", httpServer.URL+"/player/end-round", types.EndRoundRequest{}); status != 200 {
+		t.Fatalf("expected the session to survive the restart: status %v", status)
+	} else if status := send(t, client, "POST", httpServer.URL+"/player/start-round", types.StartRoundRequest{Code: []int{2, 2, 2}}); status != 200 {
+		t.Fatalf("starting the next round: status %v", status)
+	}
+}
+
+func TestStoreRestoresTokens(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.jsonl")
+	s := NewGameServer(testGames(1), "")
+	if err := s.Persist(filename); err != nil {
+		t.Fatal(err)
+	}
+	r := testRouter(t, s, Config{})
+	token := join(t, r, types.JoinRequest{PlayerName: "alice"}, 200)
+	call(t, r, "POST", "/player/start-round", token, types.StartRoundRequest{Code: []int{1, 2, 3}}, nil)
+	call(t, r, "POST", "/player/test-verifier", token, types.AskQuestionRequest{Code: []int{1, 2, 3}}, nil)
+	s.store.close()
+
+	if state, err := os.ReadFile(filename); err != nil {
+		t.Fatal(err)
+	} else if bytes.Contains(state, []byte(token)) {
+		t.Fatal("expected the state to keep only the hash of the token")
+	}
+
+	restored := NewGameServer(testGames(1), "")
+	if err := restored.Persist(filename); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.store.close()
+	r = testRouter(t, restored, Config{})
+
+	games := types.GetGamesResponse{}
+	if status := call(t, r, "GET", "/player/games", token, nil, &games); status != 200 {
+		t.Fatalf("expected the token to survive a restart: status %v", status)
+	} else if len(games.Answers[0]) != 1 {
+		t.Fatalf("expected the answer before the restart: %+v", games)
+	}
+
+	token = join(t, r, types.JoinRequest{PlayerName: "alice", Key: token}, 200)
+	if status := call(t, r, "POST", "/player/start-round", token, types.StartRoundRequest{Code: []int{2, 2, 2}}, nil); status != 200 {
+		t.Fatalf("expected rejoining after the restart to end the open round: status %v", status)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for name, config := range map[string]Config{
+		"cert without key":   {TLSCert: "cert.pem"},
+		"key without cert":   {TLSKey: "key.pem"},
+		"negative port":      {Port: -1},
+		"port out of range":  {Port: 65536},
+		"insecure same site": {CookieSameSite: "none"},
+		"unknown same site":  {CookieSameSite: "sometimes"},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Fatalf("%v: expected %+v to be invalid", name, config)
+		}
+	}
+
+	for _, config := range []Config{{}, {Port: 443, TLSCert: "cert.pem", TLSKey: "key.pem", SecureCookies: true, CookieSameSite: "None"}} {
+		if err := config.Validate(); err != nil {
+			t.Fatalf("expected %+v to be valid: %v", config, err)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(filename, []byte(`{"port": 9090, "sessionSecret": "secret", "adminKey": "admin", "playerKeys": {"bot": "key"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(config, Config{Port: 9090, SessionSecret: "secret", AdminKey: "admin", PlayerKeys: map[string]string{"bot": "key"}}) {
+		t.Fatalf("loaded %+v", config)
+	} else if config.addr() != ":9090" {
+		t.Fatalf("expected to listen on every interface, got %v", config.addr())
+	}
+
+	if err := os.WriteFile(filename, []byte(`{"port": "9090"}`), 0600); err != nil {
+		t.Fatal(err)
+	} else if _, err := LoadConfig(filename); err == nil {
+		t.Fatal("expected an error loading a malformed config")
+	}
+}
+
+func TestSessionSecret(t *testing.T) {
+	if secret, err := (Config{SessionSecret: "secret"}).sessionSecret(); err != nil || string(secret) != "secret" {
+		t.Fatalf("expected the configured secret, got %q %v", secret, err)
+	}
+
+	first, err := Config{}.sessionSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Config{}.sessionSecret()
+	if err != nil {
+		t.Fatal(err)
+	} else if bytes.Equal(first, second) {
+		t.Fatal("expected a new random secret for every run")
+	}
+}
+
+func TestListenStopsGracefully(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Config{Address: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port}
+	listener.Close()
+
+	s := NewGameServer(testGames(1), "")
+	if err := s.Persist(filepath.Join(t.TempDir(), "state.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listened := make(chan error, 1)
+	go func() {
+		listened <- s.Listen(ctx, config)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if response, err := http.Get("http://" + config.addr() + "/lobbies"); err == nil {
+			response.Body.Close()
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("server never started: ", err)
+		}
+	}
+	cancel()
+
+	select {
+	case err := <-listened:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(shutdownTimeout + 5*time.Second):
+		t.Fatal("server didn't stop")
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/types"
+)
+
+// storeVersion is the version of the state file format, state files of other versions aren't loaded
+const storeVersion = 1
+
+// Kinds of storeEntry
+const (
+	storeLobby      = "lobby"
+	storeJoin       = "join"
+	storeStartRound = "startRound"
+	storeQuestion   = "question"
+	storeEndRound   = "endRound"
+	storeGuess      = "guess"
+)
+
+// storeEntry is a line of the state file, every change to the server's state is appended as it is made. Only moves the
+// games allowed are stored, so playing them again gives the same answers.
+type storeEntry struct {
+	Version int       `json:"version"`
+	Kind    string    `json:"kind"`
+	Time    time.Time `json:"time"`
+	Lobby   string    `json:"lobby"`
+
+	// Puzzles are the games of a new lobby, with their secrets
+	Puzzles []types.Puzzle `json:"puzzles,omitempty"`
+
+	// TokenHash is the hash of the token a player was given on joining
+	TokenHash string `json:"tokenHash,omitempty"`
+
+	Player        string `json:"player,omitempty"`
+	GameIndex     int    `json:"gameIndex"`
+	Code          []int  `json:"code,omitempty"`
+	VerifierIndex int    `json:"verifierIndex"`
+}
+
+// store appends the changes to the server's state to its state file
+type store struct {
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// Persist keeps the state of the server in filename, so that a restarted server continues where it stopped. When the
+// file has a state it replaces the lobbies the server was created with, otherwise the lobbies are written to it.
+// Persist must be called before Listen.
+func (s *GameServer) Persist(filename string) error {
+	entries, err := readStore(filename)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) > 0 {
+		s.lobbies = make(map[string]*Lobby)
+		for line, entry := range entries {
+			if err := s.restore(entry); err != nil {
+				return fmt.Errorf("restoring line %v of %v : %w", line+1, filename, err)
+			}
+		}
+	}
+
+	// The state has the secrets of the games and the hashes of the players' tokens
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("opening state : %w", err)
+	}
+	s.store = &store{file: file, encoder: json.NewEncoder(file)}
+
+	if len(entries) == 0 {
+		for name, lobby := range s.lobbies {
+			puzzles, err := lobbyPuzzles(lobby.games)
+			if err != nil {
+				return err
+			}
+			s.persist(storeEntry{Kind: storeLobby, Lobby: name, Puzzles: puzzles})
+		}
+	}
+
+	return nil
+}
+
+// readStore returns the entries of a state file, none if there is no file yet. A last line left half written by a
+// crash is removed.
+func readStore(filename string) ([]storeEntry, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading state : %w", err)
+	}
+
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		fmt.Println("Removing the half written last line of", filename)
+		if err := os.Truncate(filename, int64(complete)); err != nil {
+			return nil, fmt.Errorf("removing half written line : %w", err)
+		}
+		data = data[:complete]
+	}
+
+	var entries []storeEntry
+	for line, lineData := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		if len(lineData) == 0 {
+			continue
+		}
+
+		entry := storeEntry{}
+		if err := json.Unmarshal(lineData, &entry); err != nil {
+			return nil, fmt.Errorf("reading line %v of %v : %w", line+1, filename, err)
+		} else if entry.Version != storeVersion {
+			return nil, fmt.Errorf("line %v of %v has version %v, only version %v can be loaded", line+1, filename, entry.Version, storeVersion)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// restore makes the change to the server's state an entry of the state file stores
+func (s *GameServer) restore(entry storeEntry) error {
+	if entry.Kind == storeLobby {
+		games := make([]game.Game, len(entry.Puzzles))
+		for i, puzzle := range entry.Puzzles {
+			puzzleGame, err := game.PuzzleGame(puzzle)
+			if err != nil {
+				return fmt.Errorf("puzzle %v : %w", i+1, err)
+			}
+			games[i] = puzzleGame
+		}
+
+		s.lobbies[entry.Lobby] = newLobby(entry.Lobby, games)
+		return nil
+	}
+
+	lobby := s.lobbies[entry.Lobby]
+	if lobby == nil {
+		return fmt.Errorf("no lobby %v", entry.Lobby)
+	} else if entry.Kind == storeJoin {
+		// Players join again to rejoin, with a new token
+		if lobby.players[entry.Player] == nil {
+			lobby.addPlayer(entry.Player)
+		}
+		if entry.TokenHash != "" {
+			s.setToken(entry.TokenHash, tokenOwner{lobby: lobby.name, player: entry.Player})
+		}
+		return nil
+	}
+
+	player := lobby.players[entry.Player]
+	if player == nil {
+		return fmt.Errorf("no player %v in lobby %v", entry.Player, entry.Lobby)
+	} else if entry.GameIndex < 0 || entry.GameIndex >= len(lobby.games) {
+		return fmt.Errorf("no game %v in lobby %v", entry.GameIndex, entry.Lobby)
+	}
+
+	storedGame := lobby.games[entry.GameIndex]
+	played := &lobby.history[entry.Player][entry.GameIndex]
+	switch entry.Kind {
+	case storeStartRound:
+		played.roundOpen = true
+		return storedGame.StartRound(player, entry.Code)
+	case storeQuestion:
+		result, err := storedGame.AskQuestion(player, entry.Code, entry.VerifierIndex)
+		played.answers = append(played.answers, types.Answer{Code: entry.Code, VerifierIndex: entry.VerifierIndex, Result: result})
+		return err
+	case storeEndRound:
+		played.roundOpen = false
+		return storedGame.EndRound(player)
+	case storeGuess:
+		_, err := storedGame.MakeGuess(player, entry.Code)
+		if err == nil {
+			lobby.madeGuess(entry.Player, entry.GameIndex)
+		}
+		return err
+	}
+
+	return fmt.Errorf("unknown entry %q", entry.Kind)
+}
+
+// persist appends a change to the state file, if the server has one
+func (s *GameServer) persist(entry storeEntry) {
+	if s.store == nil {
+		return
+	}
+
+	entry.Version = storeVersion
+	entry.Time = time.Now()
+
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+	if err := s.store.encoder.Encode(entry); err != nil {
+		fmt.Println("Saving state :", err)
+	}
+}
+
+// close writes the state file to disk and closes it, nothing is stored after
+func (st *store) close() {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if err := st.file.Sync(); err != nil {
+		fmt.Println("Saving state :", err)
+	} else if err := st.file.Close(); err != nil {
+		fmt.Println("Saving state :", err)
+	}
+	st.encoder = json.NewEncoder(io.Discard)
+}
+
+// lobbyPuzzles returns the puzzles of a lobby's games to store
+func lobbyPuzzles(games []game.Game) ([]types.Puzzle, error) {
+	puzzles := make([]types.Puzzle, len(games))
+	for i, lobbyGame := range games {
+		autoGame, ok := lobbyGame.(*game.AutoGame)
+		if !ok {
+			return nil, fmt.Errorf("game %v has no secret to store", i+1)
+		}
+		puzzles[i] = game.NewPuzzle(autoGame)
+	}
+
+	return puzzles, nil
+}
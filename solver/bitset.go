@@ -0,0 +1,227 @@
+package solver
+
+import (
+	"math/bits"
+	"slices"
+	"sync"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// codeMask has one bit for each code in possibleCodes
+type codeMask [2]uint64
+
+func (m codeMask) has(codeIndex int) bool {
+	return m[codeIndex/64]&(1<<(codeIndex%64)) != 0
+}
+
+func (m *codeMask) set(codeIndex int) {
+	m[codeIndex/64] |= 1 << (codeIndex % 64)
+}
+
+func (m codeMask) and(other codeMask) codeMask {
+	return codeMask{m[0] & other[0], m[1] & other[1]}
+}
+
+func (m codeMask) count() int {
+	return bits.OnesCount64(m[0]) + bits.OnesCount64(m[1])
+}
+
+// first returns the lowest code index in the mask, -1 if the mask is empty
+func (m codeMask) first() int {
+	for word, value := range m {
+		if value != 0 {
+			return word*64 + bits.TrailingZeros64(value)
+		}
+	}
+
+	return -1
+}
+
+// truthTables caches the codeMask of codes each verifier accepts
+var truthTables sync.Map
+
+// codeIndex returns the index of code in possibleCodes
+func codeIndex(code []int) int {
+	return (code[0]-1)*maxDigit*maxDigit + (code[1]-1)*maxDigit + (code[2] - 1)
+}
+
+// truthTable returns the codes accepted by verifier
+func truthTable(verifier *verifiers.Verifier) codeMask {
+	if mask, ok := truthTables.Load(verifier); ok {
+		return mask.(codeMask)
+	}
+
+	var mask codeMask
+	for i, code := range possibleCodes {
+		if verifier.Verify(code...) {
+			mask.set(i)
+		}
+	}
+
+	truthTables.Store(verifier, mask)
+	return mask
+}
+
+// solutionSet has one bit for each solution in a solutionSpace
+type solutionSet []uint64
+
+func newSolutionSet(size int) solutionSet {
+	return make(solutionSet, (size+63)/64)
+}
+
+func (s solutionSet) add(solutionIndex int) {
+	s[solutionIndex/64] |= 1 << (solutionIndex % 64)
+}
+
+func (s solutionSet) contains(solutionIndex int) bool {
+	return s[solutionIndex/64]&(1<<(solutionIndex%64)) != 0
+}
+
+func (s solutionSet) count() int {
+	count := 0
+	for _, word := range s {
+		count += bits.OnesCount64(word)
+	}
+
+	return count
+}
+
+func (s solutionSet) isEmpty() bool {
+	for _, word := range s {
+		if word != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s solutionSet) and(other solutionSet) solutionSet {
+	result := make(solutionSet, len(s))
+	for i := range s {
+		result[i] = s[i] & other[i]
+	}
+
+	return result
+}
+
+func (s solutionSet) andNot(other solutionSet) solutionSet {
+	result := make(solutionSet, len(s))
+	for i := range s {
+		result[i] = s[i] &^ other[i]
+	}
+
+	return result
+}
+
+// indexes returns the solution indexes in the set in ascending order
+func (s solutionSet) indexes() []int {
+	result := make([]int, 0, s.count())
+	for word, value := range s {
+		for value != 0 {
+			bit := bits.TrailingZeros64(value)
+			result = append(result, word*64+bit)
+			value &= value - 1
+		}
+	}
+
+	return result
+}
+
+// solutionSpace indexes the initial solutions of a game so subsets of them can be represented as solutionSets
+type solutionSpace struct {
+	solutions []game.Solution
+
+	// codes is the index in possibleCodes of each solution's code
+	codes []int
+
+	// accepts[verifierIndex][codeIndex] are the solutions whose verifier on the card accepts the code
+	accepts [][]solutionSet
+}
+
+func newSolutionSpace(cards []*verifiers.VerifierCard, solutions []game.Solution) *solutionSpace {
+	space := &solutionSpace{
+		solutions: solutions,
+		codes:     make([]int, len(solutions)),
+		accepts:   make([][]solutionSet, len(cards)),
+	}
+
+	for i, solution := range solutions {
+		space.codes[i] = codeIndex(solution.Code)
+	}
+
+	for verifierIndex := range cards {
+		space.accepts[verifierIndex] = make([]solutionSet, len(possibleCodes))
+		for codeIndex := range possibleCodes {
+			space.accepts[verifierIndex][codeIndex] = newSolutionSet(len(solutions))
+		}
+
+		for solutionIndex, solution := range solutions {
+			mask := truthTable(solution.Verifiers[verifierIndex])
+			for codeIndex := range possibleCodes {
+				if mask.has(codeIndex) {
+					space.accepts[verifierIndex][codeIndex].add(solutionIndex)
+				}
+			}
+		}
+	}
+
+	return space
+}
+
+func (space *solutionSpace) all() solutionSet {
+	all := newSolutionSet(len(space.solutions))
+	for i := range space.solutions {
+		all.add(i)
+	}
+
+	return all
+}
+
+// split returns the solutions in candidates that give the answer valid when code is tested against the verifier
+func (space *solutionSpace) split(candidates solutionSet, code []int, verifierIndex int, valid bool) solutionSet {
+	accepts := space.accepts[verifierIndex][codeIndex(code)]
+	if valid {
+		return candidates.and(accepts)
+	}
+
+	return candidates.andNot(accepts)
+}
+
+// codeMask returns the distinct codes of the solutions in the set
+func (space *solutionSpace) codeMask(set solutionSet) codeMask {
+	var mask codeMask
+	for word, value := range set {
+		for value != 0 {
+			bit := bits.TrailingZeros64(value)
+			mask.set(space.codes[word*64+bit])
+			value &= value - 1
+		}
+	}
+
+	return mask
+}
+
+func (space *solutionSpace) countCodes(set solutionSet) int {
+	return space.codeMask(set).count()
+}
+
+// indexOf returns the index of the solution using the same verifiers, -1 if there isn't one
+func (space *solutionSpace) indexOf(solution game.Solution) int {
+	return slices.IndexFunc(space.solutions, func(candidate game.Solution) bool {
+		return slices.Equal(candidate.Verifiers, solution.Verifiers)
+	})
+}
+
+// toSolutions returns the solutions in the set
+func (space *solutionSpace) toSolutions(set solutionSet) []game.Solution {
+	indexes := set.indexes()
+	result := make([]game.Solution, len(indexes))
+	for i, index := range indexes {
+		result[i] = space.solutions[index]
+	}
+
+	return result
+}
@@ -8,12 +8,16 @@ const maxDigit = 5
 
 var possibleCodes [][]int
 
+// allCodes has every code in possibleCodes
+var allCodes codeMask
+
 // possibleCodes returns a channel that will send all possible codes, and cleanup function to end generation.
 func init() {
 	possibleCodes = make([][]int, 0, int(math.Pow(maxDigit, 3)))
 	for i := 1; i <= maxDigit; i++ {
 		for j := 1; j <= maxDigit; j++ {
 			for k := 1; k <= maxDigit; k++ {
+				allCodes.set(len(possibleCodes))
 				possibleCodes = append(possibleCodes, []int{i, j, k})
 			}
 		}
@@ -1,5 +1,10 @@
 package solver
 
+func init() {
+	RegisterVerifierStrategy("combine", "Scores a verifier by the solutions eliminated by both answers", nil, fixed[VerifierStrategy](scoreVerifierWithMostEliminations))
+	RegisterSolver("combine", "Top three codes with the combine verifier strategy", nil, noParams(CombineEliminated))
+}
+
 func CombineEliminated() *Solver {
 	return &Solver{
 		name:             "CombineEliminated",
@@ -9,14 +14,14 @@ func CombineEliminated() *Solver {
 }
 
 // scoreVerifierWithMostEliminations scores verifier based off how many solutions are eliminated in all cases.
-func scoreVerifierWithMostEliminations(s *Solver, verifierIndex int, code []int) int {
+func scoreVerifierWithMostEliminations(s *Session, verifierIndex int, code []int) int {
 	score := 0
-	solutionsIfTrue := len(s.adjustSolutions(code, verifierIndex, true))
+	solutionsIfTrue := s.adjustSolutions(code, verifierIndex, true).count()
 	if solutionsIfTrue > 0 {
 		score += len(s.solutions) - solutionsIfTrue
 	}
 
-	solutionsIfFalse := len(s.adjustSolutions(code, verifierIndex, false))
+	solutionsIfFalse := s.adjustSolutions(code, verifierIndex, false).count()
 	if solutionsIfFalse > 0 {
 		score += len(s.solutions) - solutionsIfFalse
 	}
@@ -0,0 +1,100 @@
+package solver
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/stat/combin"
+)
+
+// informationScale converts bits of information into the integer scores used by strategies
+const informationScale = 1_000_000
+
+func init() {
+	RegisterCodeStrategy("entropy", "Scores a code by the expected information of its best three verifiers", nil, fixed[CodeStrategy](entropyCodeStrategy))
+	RegisterVerifierStrategy("entropy", "Scores a verifier by the expected information of its answer", nil, fixed[VerifierStrategy](entropyVerifierStrategy))
+	RegisterSolver("entropy", "Maximizes the expected information about the code", nil, noParams(Entropy))
+}
+
+func Entropy() *Solver {
+	return &Solver{
+		name:             "Entropy",
+		codeStrategy:     entropyCodeStrategy,
+		verifierStrategy: entropyVerifierStrategy,
+	}
+}
+
+// entropyVerifierStrategy scores a verifier by the expected information its answer gives about the code
+func entropyVerifierStrategy(s *Session, verifierIndex int, code []int) int {
+	return informationScore(s, s.candidates, partitionSolutions(s, code, []int{verifierIndex}))
+}
+
+// entropyCodeStrategy scores a code by the expected information of the best three verifiers asked against it
+func entropyCodeStrategy(s *Session, code []int) int {
+	nVerifiers := len(s.game.GetVerifierCards())
+	combinations := combin.Combinations(nVerifiers, min(3, nVerifiers))
+	bestScore := 0
+	for _, combination := range combinations {
+		score := informationScore(s, s.candidates, partitionSolutions(s, code, combination))
+		if score > bestScore {
+			bestScore = score
+		}
+	}
+
+	return bestScore
+}
+
+// partitionSolutions groups the candidates by the answers the verifiers would give for code
+func partitionSolutions(s *Session, code []int, verifierIndexes []int) []solutionSet {
+	partitions := []solutionSet{s.candidates}
+	for _, verifierIndex := range verifierIndexes {
+		nextPartitions := make([]solutionSet, 0, len(partitions)*2)
+		for _, partition := range partitions {
+			for _, valid := range []bool{true, false} {
+				if split := s.filterSolutions(partition, code, verifierIndex, valid); !split.isEmpty() {
+					nextPartitions = append(nextPartitions, split)
+				}
+			}
+		}
+		partitions = nextPartitions
+	}
+
+	return partitions
+}
+
+// informationScore returns the expected information gained by splitting solutions into partitions.
+// Distinct codes are what matter, solution counts only break ties between equally informative splits.
+func informationScore(s *Session, solutions solutionSet, partitions []solutionSet) int {
+	codeCounts := make([]int, len(partitions))
+	solutionCounts := make([]int, len(partitions))
+	for i, partition := range partitions {
+		codeCounts[i] = s.countCodes(partition)
+		solutionCounts[i] = partition.count()
+	}
+
+	codeGain := expectedInformation(s.countCodes(solutions), codeCounts)
+	solutionGain := expectedInformation(solutions.count(), solutionCounts)
+
+	return int(math.Round(codeGain*informationScale + solutionGain*informationScale/1000))
+}
+
+// expectedInformation returns log2(total) minus the expected log2 of the partition sizes,
+// with each partition weighted by its size.
+func expectedInformation(total int, partitionSizes []int) float64 {
+	if total <= 1 {
+		return 0
+	}
+
+	sizeSum := 0
+	for _, size := range partitionSizes {
+		sizeSum += size
+	}
+
+	remaining := 0.0
+	for _, size := range partitionSizes {
+		if size > 0 {
+			remaining += float64(size) / float64(sizeSum) * math.Log2(float64(size))
+		}
+	}
+
+	return max(0, math.Log2(float64(total))-remaining)
+}
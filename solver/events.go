@@ -0,0 +1,131 @@
+package solver
+
+import (
+	"encoding/json"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// Event is something that happened while a Session was solving a game.
+// Verifiers are identified by their index in the game's cards, starting at 0.
+type Event interface {
+	// EventName identifies the type of event in logs
+	EventName() string
+}
+
+// Observer is told about every event of the sessions it observes. Sessions of one Solver may run at the same time
+// so observers shared between games must be safe to call concurrently.
+type Observer interface {
+	Observe(s *Session, event Event)
+}
+
+// ObserverFunc adapts a function to an Observer
+type ObserverFunc func(s *Session, event Event)
+
+func (f ObserverFunc) Observe(s *Session, event Event) {
+	f(s, event)
+}
+
+// SolveStarted is the first event of every Solve
+type SolveStarted struct {
+	Player string `json:"player"`
+	Cards  int    `json:"cards"`
+}
+
+// InitialSolutionsFound is the number of solutions before any questions are asked
+type InitialSolutionsFound struct {
+	Solutions int `json:"solutions"`
+	Codes     int `json:"codes"`
+}
+
+// CodeSelected is the code chosen for the next round, Plan is only set by combined strategies
+type CodeSelected struct {
+	Code        []int        `json:"code"`
+	Score       int          `json:"score"`
+	Plan        []int        `json:"plan,omitempty"`
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// VerifierSelected is the verifier chosen for the next question with the solutions left by each answer
+type VerifierSelected struct {
+	Code     []int `json:"code"`
+	Verifier int   `json:"verifier"`
+	Score    int   `json:"score"`
+	IfTrue   int   `json:"ifTrue"`
+	IfFalse  int   `json:"ifFalse"`
+
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// NoUsefulVerifier ends a round early because no verifier splits the solutions for the code
+type NoUsefulVerifier struct {
+	Code []int `json:"code"`
+}
+
+// AnswerReceived is the game's answer to a question
+type AnswerReceived struct {
+	Code     []int `json:"code"`
+	Verifier int   `json:"verifier"`
+	Valid    bool  `json:"valid"`
+}
+
+// SolutionsEliminated follows every answer with the solutions that are still possible
+type SolutionsEliminated struct {
+	Eliminated int `json:"eliminated"`
+	Remaining  int `json:"remaining"`
+	Codes      int `json:"codes"`
+}
+
+// ContradictionDetected is an answer that contradicts every solution left, so some answer must be wrong.
+// Each of the Suspects is a smallest set of answers that would explain the contradiction by being wrong,
+// Plausible solutions contradict only WrongAnswers answers and are the candidates from now on.
+type ContradictionDetected struct {
+	WrongAnswers int             `json:"wrongAnswers"`
+	Suspects     [][]game.Answer `json:"suspects"`
+	Plausible    int             `json:"plausible"`
+}
+
+// GuessMade is the final guess and whether the game accepted it
+type GuessMade struct {
+	Code      []int                 `json:"code"`
+	Verifiers []*verifiers.Verifier `json:"-"`
+	Correct   bool                  `json:"correct"`
+}
+
+// SolveFinished is the last event of every Solve, Err is set when Solve returned an error
+type SolveFinished struct {
+	Result Result
+	Err    error
+}
+
+func (SolveStarted) EventName() string          { return "solveStarted" }
+func (InitialSolutionsFound) EventName() string { return "initialSolutionsFound" }
+func (CodeSelected) EventName() string          { return "codeSelected" }
+func (VerifierSelected) EventName() string      { return "verifierSelected" }
+func (NoUsefulVerifier) EventName() string      { return "noUsefulVerifier" }
+func (AnswerReceived) EventName() string        { return "answerReceived" }
+func (SolutionsEliminated) EventName() string   { return "solutionsEliminated" }
+func (ContradictionDetected) EventName() string { return "contradictionDetected" }
+func (GuessMade) EventName() string             { return "guessMade" }
+func (SolveFinished) EventName() string         { return "solveFinished" }
+
+func (e SolveFinished) MarshalJSON() ([]byte, error) {
+	finished := struct {
+		Correct        bool   `json:"correct"`
+		Code           []int  `json:"code,omitempty"`
+		CodesTested    int    `json:"codesTested"`
+		QuestionsAsked int    `json:"questionsAsked"`
+		Error          string `json:"error,omitempty"`
+	}{
+		Correct:        e.Result.Correct,
+		Code:           e.Result.Code,
+		CodesTested:    e.Result.CodesTested,
+		QuestionsAsked: e.Result.QuestionsAsked,
+	}
+	if e.Err != nil {
+		finished.Error = e.Err.Error()
+	}
+
+	return json.Marshal(finished)
+}
@@ -0,0 +1,175 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// runnersUpCount is the number of alternatives kept in explanations
+const runnersUpCount = 3
+
+// Split is the distinct codes left by each answer of a verifier
+type Split struct {
+	Verifier int `json:"verifier"`
+	IfTrue   int `json:"ifTrue"`
+	IfFalse  int `json:"ifFalse"`
+}
+
+// Alternative is a code or verifier the strategy scored, Verifier is -1 for codes
+type Alternative struct {
+	Code     []int `json:"code"`
+	Verifier int   `json:"verifier"`
+	Score    int   `json:"score"`
+}
+
+// Explanation is why a code or verifier was chosen, only made for solvers WithExplanations
+type Explanation struct {
+	// Unsolved are the verifier cards whose verifier differs between the remaining codes
+	Unsolved []int `json:"unsolved"`
+
+	// Splits are the codes left by each answer, for every useful verifier of a code or for the chosen verifier
+	Splits []Split `json:"splits"`
+
+	// RunnersUp are the next best choices of the strategy, best first
+	RunnersUp []Alternative `json:"runnersUp"`
+
+	// Reask is set when a question is asked again because its answer may have been wrong
+	Reask bool `json:"reask,omitempty"`
+
+	// Planned is set when the verifier was chosen along with the code by a combined strategy
+	Planned bool `json:"planned,omitempty"`
+}
+
+// WithExplanations returns a copy of the solver that explains every code and verifier it chooses in its events
+func (s *Solver) WithExplanations() *Solver {
+	withExplanations := *s
+	withExplanations.explain = true
+	return &withExplanations
+}
+
+// ranking keeps the best scoring alternatives, in the order a strategy would pick them when explaining
+type ranking struct {
+	enabled bool
+	best    []Alternative
+}
+
+func (r *ranking) add(alternative Alternative) {
+	if !r.enabled {
+		return
+	}
+
+	// Earlier alternatives win ties, the same as the strategies
+	position := len(r.best)
+	for position > 0 && r.best[position-1].Score < alternative.Score {
+		position -= 1
+	}
+
+	if position <= runnersUpCount {
+		r.best = slices.Insert(r.best, position, alternative)
+		r.best = r.best[:min(len(r.best), runnersUpCount+1)]
+	}
+}
+
+// runnersUp returns the alternatives after the best one
+func (r *ranking) runnersUp() []Alternative {
+	if len(r.best) == 0 {
+		return nil
+	}
+
+	return r.best[1:]
+}
+
+// split returns the codes left by each answer of verifierIndex for code
+func (s *Session) split(code []int, verifierIndex int) Split {
+	return Split{
+		Verifier: verifierIndex,
+		IfTrue:   s.countCodes(s.adjustSolutions(code, verifierIndex, true)),
+		IfFalse:  s.countCodes(s.adjustSolutions(code, verifierIndex, false)),
+	}
+}
+
+func (s *Session) explainCode(code []int, runnersUp []Alternative, reask bool) *Explanation {
+	explanation := &Explanation{
+		Unsolved:  unsolvedVerifiers(s),
+		RunnersUp: runnersUp,
+		Reask:     reask,
+	}
+
+	for verifierIndex := range s.game.GetVerifierCards() {
+		if split := s.split(code, verifierIndex); split.IfTrue > 0 && split.IfFalse > 0 {
+			explanation.Splits = append(explanation.Splits, split)
+		}
+	}
+
+	return explanation
+}
+
+func (s *Session) explainVerifier(code []int, verifierIndex int, runnersUp []Alternative, reask bool) *Explanation {
+	return &Explanation{
+		Unsolved:  unsolvedVerifiers(s),
+		Splits:    []Split{s.split(code, verifierIndex)},
+		RunnersUp: runnersUp,
+		Reask:     reask,
+		Planned:   !reask && s.solver.combinedStrategy != nil,
+	}
+}
+
+// ExplainObserver writes the explanation of every choice, solvers must be WithExplanations for there to be any
+func ExplainObserver(w io.Writer) Observer {
+	lock := sync.Mutex{}
+	return ObserverFunc(func(s *Session, event Event) {
+		var explanation strings.Builder
+		switch event := event.(type) {
+		case CodeSelected:
+			if event.Explanation == nil {
+				return
+			}
+
+			fmt.Fprintf(&explanation, "%v chose code %v with score %v", s.GetPlayerName(), event.Code, event.Score)
+			writeExplanation(&explanation, event.Explanation)
+		case VerifierSelected:
+			if event.Explanation == nil {
+				return
+			}
+
+			fmt.Fprintf(&explanation, "%v chose verifier %v for code %v with score %v", s.GetPlayerName(), event.Verifier+1, event.Code, event.Score)
+			writeExplanation(&explanation, event.Explanation)
+		default:
+			return
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		fmt.Fprint(w, explanation.String())
+	})
+}
+
+func writeExplanation(w io.Writer, explanation *Explanation) {
+	if explanation.Reask {
+		fmt.Fprint(w, ", asking again because an answer may be wrong")
+	} else if explanation.Planned {
+		fmt.Fprint(w, ", planned with the code")
+	}
+	fmt.Fprintln(w)
+
+	unsolved := make([]string, len(explanation.Unsolved))
+	for i, verifierIndex := range explanation.Unsolved {
+		unsolved[i] = fmt.Sprint(verifierIndex + 1)
+	}
+	fmt.Fprintf(w, "  Unsolved verifiers: %v\n", strings.Join(unsolved, ", "))
+
+	for _, split := range explanation.Splits {
+		fmt.Fprintf(w, "  Verifier %v leaves %v codes if true, %v codes if false\n", split.Verifier+1, split.IfTrue, split.IfFalse)
+	}
+
+	for _, alternative := range explanation.RunnersUp {
+		if alternative.Verifier == -1 {
+			fmt.Fprintf(w, "  Runner up: code %v with score %v\n", alternative.Code, alternative.Score)
+		} else {
+			fmt.Fprintf(w, "  Runner up: verifier %v with score %v\n", alternative.Verifier+1, alternative.Score)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package solver
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/caseymerrill/turingsolver/game"
+)
+
+// Hint is the recommended next question of a game played somewhere else, along with the questions that would follow it
+// in the same round after each answer
+type Hint struct {
+	// Solved is set when the code is known, Code is then the code to guess. Unconfirmed is set as well when the
+	// solver wants to ask again about answers that may be wrong before guessing.
+	Solved      bool
+	Unconfirmed bool
+
+	// Code to test, and the Verifier to test it against
+	Code     []int
+	Verifier int
+
+	// Reask is set when the question was asked before but the answer may have been wrong
+	Reask bool
+
+	// IfTrue and IfFalse follow each answer, nil when the round is over or the answer is impossible
+	IfTrue  *Hint
+	IfFalse *Hint
+}
+
+// Apply records the answer to a question asked outside of Solve, such as at a table game. Questions about the same
+// code in a row are one round, as long as the round has questions left.
+func (s *Session) Apply(answer game.Answer) error {
+	if len(s.space.solutions) == 0 {
+		return ErrNoSolutions
+	} else if answer.Verifier < 0 || answer.Verifier >= len(s.game.GetVerifierCards()) {
+		return fmt.Errorf("no verifier %v in a game with %v cards", answer.Verifier+1, len(s.game.GetVerifierCards()))
+	} else if len(answer.Code) != len(possibleCodes[0]) {
+		return fmt.Errorf("not a code: %v", answer.Code)
+	}
+
+	if len(s.answers) > 0 && slices.Equal(s.answers[len(s.answers)-1].Code, answer.Code) && s.verifiersTestedThisCode < s.questionsPerRound {
+		s.verifiersTestedThisCode += 1
+	} else {
+		// A new round follows the plan Solve would have made if the code is the one it would have chosen
+		s.verifiersTestedThisCode = 1
+		s.plan, s.reasking = nil, false
+		if code, plan := s.nextReask(); code != nil {
+			if slices.Equal(code, answer.Code) {
+				s.plan, s.reasking = plan, true
+			}
+		} else if s.solver.combinedStrategy != nil {
+			_, s.plan = s.solver.combinedStrategy(s, answer.Code)
+		}
+	}
+
+	// Keep following the plan for the code as long as the questions come from it
+	if planned := slices.Index(s.plan, answer.Verifier); planned != -1 {
+		s.plan = s.plan[planned+1:]
+	} else {
+		s.plan, s.reasking = nil, false
+	}
+
+	return s.record(answer)
+}
+
+// Recommend returns what the solver would ask next, finishing the round in progress when it still has useful questions.
+// The session is not changed.
+func (s *Session) Recommend() (*Hint, error) {
+	if len(s.solutions) == 0 {
+		return nil, ErrNoSolutions
+	} else if s.solved() {
+		return &Hint{Solved: true, Code: s.solutions[0].Code, Verifier: -1}, nil
+	}
+
+	if s.verifiersTestedThisCode > 0 && s.verifiersTestedThisCode < s.questionsPerRound {
+		if hint := s.hypothetical().hintRound(s.answers[len(s.answers)-1].Code, s.plan, s.reasking); hint != nil {
+			return hint, nil
+		}
+	}
+
+	next := s.hypothetical()
+	next.verifiersTestedThisCode = 0
+	code, plan := next.nextReask()
+	reask := code != nil
+	if !reask && s.solver.combinedStrategy != nil {
+		code, _, plan, _ = next.selectCodeAndPlan()
+	} else if !reask {
+		code, _, _ = next.selectCode()
+	}
+
+	if hint := next.hintRound(code, plan, reask); hint != nil {
+		return hint, nil
+	}
+
+	return nil, fmt.Errorf("no useful questions for code %v", code)
+}
+
+// hypothetical returns a copy of the session that can record answers without changing the session or telling observers
+func (s *Session) hypothetical() *Session {
+	quiet := *s.solver
+	quiet.observers = nil
+
+	copied := *s
+	copied.solver = &quiet
+	copied.answers = slices.Clip(s.answers)
+	return &copied
+}
+
+// hintRound returns the next question of a round testing code and the questions following each answer
+func (s *Session) hintRound(code []int, plan []int, reask bool) *Hint {
+	if s.verifiersTestedThisCode >= s.questionsPerRound {
+		return nil
+	}
+
+	var verifier int
+	if reask && len(plan) == 0 {
+		return nil
+	} else if reask {
+		verifier, plan = plan[0], plan[1:]
+	} else if s.solver.combinedStrategy != nil {
+		verifier, plan = s.selectPlannedVerifier(code, plan)
+	} else {
+		verifier, _, _ = s.selectVerifier(code)
+	}
+
+	if verifier == -1 {
+		return nil
+	}
+
+	hint := &Hint{Code: code, Verifier: verifier, Reask: reask}
+	for _, valid := range []bool{true, false} {
+		next := s.hypothetical()
+		next.verifiersTestedThisCode += 1
+		if err := next.record(game.Answer{Code: code, Verifier: verifier, Valid: valid}); err != nil {
+			continue
+		}
+
+		var followUp *Hint
+		if next.hasSolution() {
+			followUp = &Hint{Solved: true, Unconfirmed: !next.solved(), Code: next.solutions[0].Code, Verifier: -1}
+		} else {
+			followUp = next.hintRound(code, plan, reask)
+		}
+
+		if valid {
+			hint.IfTrue = followUp
+		} else {
+			hint.IfFalse = followUp
+		}
+	}
+
+	return hint
+}
@@ -0,0 +1,140 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// lookaheadScale converts the fractional remaining code counts of a decision tree into integer scores
+const lookaheadScale = 1_000
+
+type lookaheadMode int
+
+const (
+	// worstCase assumes every answer leaves the most codes
+	worstCase lookaheadMode = iota
+	// expectedCase weights answers by how many solutions give them, solutions are equally likely to be the secret
+	expectedCase
+)
+
+func init() {
+	modeParam := []Param{{Name: "mode", Description: "worst or expected outcome of the answers", Default: "worst"}}
+	RegisterCodeStrategy("lookahead", "Scores a code by searching every sequence of questions in a round", modeParam, func(params Params) (CodeStrategy, error) {
+		mode, err := parseLookaheadMode(params)
+		if err != nil {
+			return nil, err
+		}
+
+		return lookaheadCodeStrategy(mode), nil
+	})
+	RegisterVerifierStrategy("lookahead", "Scores a verifier by searching every sequence of questions left this code", modeParam, func(params Params) (VerifierStrategy, error) {
+		mode, err := parseLookaheadMode(params)
+		if err != nil {
+			return nil, err
+		}
+
+		return lookaheadVerifierStrategy(mode), nil
+	})
+	RegisterSolver("minimax", "Lookahead minimizing the worst case codes left", nil, noParams(Minimax))
+	RegisterSolver("expectimax", "Lookahead minimizing the expected codes left", nil, noParams(Expectimax))
+}
+
+func parseLookaheadMode(params Params) (lookaheadMode, error) {
+	switch mode := params.String("mode", "worst"); strings.ToLower(mode) {
+	case "worst":
+		return worstCase, nil
+	case "expected":
+		return expectedCase, nil
+	default:
+		return 0, fmt.Errorf("unknown lookahead mode: %v", mode)
+	}
+}
+
+// Minimax plans every question of a code and picks the code whose worst-case outcome leaves the fewest codes
+func Minimax() *Solver {
+	return &Solver{
+		name:             "Minimax",
+		codeStrategy:     lookaheadCodeStrategy(worstCase),
+		verifierStrategy: lookaheadVerifierStrategy(worstCase),
+	}
+}
+
+// Expectimax plans every question of a code and picks the code whose expected outcome leaves the fewest codes
+func Expectimax() *Solver {
+	return &Solver{
+		name:             "Expectimax",
+		codeStrategy:     lookaheadCodeStrategy(expectedCase),
+		verifierStrategy: lookaheadVerifierStrategy(expectedCase),
+	}
+}
+
+// lookaheadCodeStrategy scores a code by searching every adaptive sequence of questions in a full round
+func lookaheadCodeStrategy(mode lookaheadMode) CodeStrategy {
+	return func(s *Session, code []int) int {
+		remaining := lookahead(s, mode, s.candidates, code, s.questionsPerRound)
+		return lookaheadScore(s, remaining)
+	}
+}
+
+// lookaheadVerifierStrategy scores a verifier as the first question of the best tree for the questions left this code
+func lookaheadVerifierStrategy(mode lookaheadMode) VerifierStrategy {
+	return func(s *Session, verifierIndex int, code []int) int {
+		questionsLeft := max(1, s.questionsPerRound-s.verifiersTestedThisCode)
+		trueSolutions := s.adjustSolutions(code, verifierIndex, true)
+		falseSolutions := s.adjustSolutions(code, verifierIndex, false)
+		if trueSolutions.isEmpty() || falseSolutions.isEmpty() {
+			return 0
+		}
+
+		remaining := combineBranches(mode,
+			lookahead(s, mode, trueSolutions, code, questionsLeft-1), trueSolutions.count(),
+			lookahead(s, mode, falseSolutions, code, questionsLeft-1), falseSolutions.count(),
+		)
+
+		// Any verifier that splits the solutions is better than none
+		return max(1, lookaheadScore(s, remaining))
+	}
+}
+
+func lookaheadScore(s *Session, remaining float64) int {
+	return int(math.Round((lookaheadLeaf(s, s.candidates) - remaining) * lookaheadScale))
+}
+
+// lookahead returns the remaining codes after asking up to questionsLeft more verifiers about code, choosing each
+// verifier after seeing the previous answer.
+func lookahead(s *Session, mode lookaheadMode, solutions solutionSet, code []int, questionsLeft int) float64 {
+	best := lookaheadLeaf(s, solutions)
+	if questionsLeft == 0 || s.countCodes(solutions) <= 1 {
+		return best
+	}
+
+	for verifierIndex := range s.game.GetVerifierCards() {
+		trueSolutions := s.filterSolutions(solutions, code, verifierIndex, true)
+		falseSolutions := s.filterSolutions(solutions, code, verifierIndex, false)
+		if trueSolutions.isEmpty() || falseSolutions.isEmpty() {
+			continue
+		}
+
+		remaining := combineBranches(mode,
+			lookahead(s, mode, trueSolutions, code, questionsLeft-1), trueSolutions.count(),
+			lookahead(s, mode, falseSolutions, code, questionsLeft-1), falseSolutions.count(),
+		)
+		best = min(best, remaining)
+	}
+
+	return best
+}
+
+// lookaheadLeaf values a set of solutions by its distinct codes, remaining solutions break ties
+func lookaheadLeaf(s *Session, solutions solutionSet) float64 {
+	return float64(s.countCodes(solutions)) + float64(solutions.count())/float64(len(s.solutions)+1)
+}
+
+func combineBranches(mode lookaheadMode, trueRemaining float64, trueWeight int, falseRemaining float64, falseWeight int) float64 {
+	if mode == expectedCase {
+		return (trueRemaining*float64(trueWeight) + falseRemaining*float64(falseWeight)) / float64(trueWeight+falseWeight)
+	}
+
+	return max(trueRemaining, falseRemaining)
+}
@@ -0,0 +1,202 @@
+package solver
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/caseymerrill/turingsolver/game"
+)
+
+// NoiseMode is how a solver handles answers that may be wrong. Every answer is kept so the solutions can be ranked by
+// how many answers they contradict, the fewer the more plausible. A code is only guessed once it would take one more
+// wrong answer than the most plausible solutions already need for the code to be different.
+type NoiseMode int
+
+const (
+	// NoiseOff trusts every answer and gives up with ErrContradictoryAnswers
+	NoiseOff NoiseMode = iota
+	// NoiseReask plays the most plausible solutions, then asks again the questions the other codes disagree with
+	NoiseReask
+	// NoisePlausible plays every solution within one wrong answer of the most plausible ones
+	NoisePlausible
+)
+
+func (m NoiseMode) String() string {
+	switch m {
+	case NoiseReask:
+		return "reask"
+	case NoisePlausible:
+		return "plausible"
+	default:
+		return "off"
+	}
+}
+
+// ParseNoiseMode returns the mode named by String
+func ParseNoiseMode(name string) (NoiseMode, error) {
+	for _, mode := range []NoiseMode{NoiseOff, NoiseReask, NoisePlausible} {
+		if strings.EqualFold(mode.String(), name) {
+			return mode, nil
+		}
+	}
+
+	return NoiseOff, fmt.Errorf("unknown noise mode: %v", name)
+}
+
+// mismatches returns the number of answers each solution of the space contradicts
+func (s *Session) mismatches() []int {
+	counts := make([]int, len(s.space.solutions))
+	for _, answer := range s.answers {
+		accepts := s.space.accepts[answer.Verifier][codeIndex(answer.Code)]
+		for solutionIndex := range counts {
+			if accepts.contains(solutionIndex) != answer.Valid {
+				counts[solutionIndex] += 1
+			}
+		}
+	}
+
+	return counts
+}
+
+// withinMismatches returns the solutions that contradict at most limit answers
+func (s *Session) withinMismatches(counts []int, limit int) solutionSet {
+	set := newSolutionSet(len(counts))
+	for solutionIndex, count := range counts {
+		if count <= limit {
+			set.add(solutionIndex)
+		}
+	}
+
+	return set
+}
+
+// updatePlausible ranks the solutions by the answers they contradict and makes the candidates the ones the noise mode
+// plays. Returns whether every solution contradicts more answers than before, along with the event describing it.
+func (s *Session) updatePlausible() (ContradictionDetected, bool) {
+	counts := s.mismatches()
+	fewest := slices.Min(counts)
+	if s.solver.noise == NoisePlausible {
+		s.setCandidates(s.withinMismatches(counts, fewest+1))
+	} else {
+		s.setCandidates(s.withinMismatches(counts, fewest))
+	}
+
+	if fewest <= s.wrongAnswers {
+		return ContradictionDetected{}, false
+	}
+	s.wrongAnswers = fewest
+
+	// Each of the most plausible solutions has a smallest set of answers that must be wrong for it to be the secret
+	var suspects [][]game.Answer
+	seen := make(map[string]bool)
+	for solutionIndex, count := range counts {
+		if count != fewest {
+			continue
+		}
+
+		var wrong []game.Answer
+		for _, answer := range s.answers {
+			if s.space.accepts[answer.Verifier][codeIndex(answer.Code)].contains(solutionIndex) != answer.Valid {
+				wrong = append(wrong, answer)
+			}
+		}
+
+		if key := fmt.Sprint(wrong); !seen[key] {
+			seen[key] = true
+			suspects = append(suspects, wrong)
+		}
+	}
+
+	return ContradictionDetected{
+		WrongAnswers: fewest,
+		Suspects:     suspects,
+		Plausible:    s.withinMismatches(counts, fewest).count(),
+	}, true
+}
+
+// rivals returns the solutions within one wrong answer of the most plausible ones that have a different code,
+// only meaningful once the most plausible solutions agree on the code
+func (s *Session) rivals(counts []int) solutionSet {
+	rivals := s.withinMismatches(counts, s.wrongAnswers+1)
+	leading := codeIndex(s.solutions[0].Code)
+	for _, solutionIndex := range rivals.indexes() {
+		if s.space.codes[solutionIndex] == leading {
+			rivals[solutionIndex/64] &^= 1 << (solutionIndex % 64)
+		}
+	}
+
+	return rivals
+}
+
+// solved returns true once the code can be guessed
+func (s *Session) solved() bool {
+	if !s.hasSolution() {
+		return false
+	} else if s.solver.noise != NoiseReask {
+		return true
+	}
+
+	return s.rivals(s.mismatches()).isEmpty()
+}
+
+// nextReask returns a code to test again and the verifiers whose answers for it rule out the most rivals while agreeing
+// with the most plausible solution, so the rivals need another wrong answer once the answers are confirmed.
+// Returns nil when there are no rivals.
+func (s *Session) nextReask() ([]int, []int) {
+	if s.solver.noise != NoiseReask || !s.hasSolution() {
+		return nil, nil
+	}
+
+	rivals := s.rivals(s.mismatches())
+	if rivals.isEmpty() {
+		return nil, nil
+	}
+
+	// Value of asking each question again is the number of rivals its answers contradict
+	type question struct {
+		code     int
+		verifier int
+	}
+	leading := s.space.indexOf(s.solutions[0])
+	values := make(map[question]int)
+	for _, answer := range s.answers {
+		if s.space.accepts[answer.Verifier][codeIndex(answer.Code)].contains(leading) != answer.Valid {
+			continue
+		}
+
+		contradicted := rivals.and(s.space.accepts[answer.Verifier][codeIndex(answer.Code)])
+		if answer.Valid {
+			contradicted = rivals.andNot(s.space.accepts[answer.Verifier][codeIndex(answer.Code)])
+		}
+		values[question{code: codeIndex(answer.Code), verifier: answer.Verifier}] += contradicted.count()
+	}
+
+	codeValues := make(map[int]int)
+	for q, value := range values {
+		codeValues[q.code] += value
+	}
+
+	bestCode := -1
+	for code, value := range codeValues {
+		if bestCode == -1 || value > codeValues[bestCode] || (value == codeValues[bestCode] && code < bestCode) {
+			bestCode = code
+		}
+	}
+
+	var plan []int
+	for q, value := range values {
+		if q.code == bestCode && value > 0 {
+			plan = append(plan, q.verifier)
+		}
+	}
+	slices.SortFunc(plan, func(a, b int) int {
+		if valueA, valueB := values[question{code: bestCode, verifier: a}], values[question{code: bestCode, verifier: b}]; valueA != valueB {
+			return valueB - valueA
+		}
+
+		return a - b
+	})
+
+	return possibleCodes[bestCode], plan[:min(len(plan), s.questionsPerRound)]
+}
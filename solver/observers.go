@@ -0,0 +1,115 @@
+package solver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caseymerrill/turingsolver/game"
+)
+
+// TextObserver writes the solutions that are still possible after every answer, as interactive mode always has
+func TextObserver(w io.Writer) Observer {
+	return ObserverFunc(func(s *Session, event Event) {
+		switch event := event.(type) {
+		case InitialSolutionsFound, SolutionsEliminated:
+			report := fmt.Sprintf("Found %v solutions:\n", len(s.Solutions()))
+			for _, solution := range s.Solutions() {
+				report += fmt.Sprintf("  %v\n", solution)
+			}
+			fmt.Fprintln(w, report)
+		case ContradictionDetected:
+			fmt.Fprintf(w, "Answers contradict every solution, %v answers must be wrong. Suspects:\n", event.WrongAnswers)
+			for _, wrong := range event.Suspects {
+				fmt.Fprintf(w, "  %v\n", describeAnswers(wrong))
+			}
+		case NoUsefulVerifier:
+			fmt.Fprintln(w, "No useful verifiers for code", event.Code)
+		}
+	})
+}
+
+// describeAnswers lists answers the way interactive mode asks the questions, verifiers numbered from 1
+func describeAnswers(answers []game.Answer) string {
+	descriptions := make([]string, len(answers))
+	for i, answer := range answers {
+		descriptions[i] = fmt.Sprintf("code %v verifier %v answered %v", answer.Code, answer.Verifier+1, answer.Valid)
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// SummaryObserver writes one line for every finished Solve
+func SummaryObserver(w io.Writer) Observer {
+	lock := sync.Mutex{}
+	return ObserverFunc(func(s *Session, event Event) {
+		finished, ok := event.(SolveFinished)
+		if !ok {
+			return
+		}
+
+		outcome := "guessed wrong"
+		if finished.Err != nil {
+			outcome = "failed: " + finished.Err.Error()
+		} else if finished.Result.Correct {
+			outcome = "guessed correctly"
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		fmt.Fprintf(w, "%v: %v codes, %v questions, %v\n", s.GetPlayerName(), finished.Result.CodesTested, finished.Result.QuestionsAsked, outcome)
+	})
+}
+
+// jsonObserver writes every event as a line of JSON, sessions are numbered in the order they start
+type jsonObserver struct {
+	lock     sync.Mutex
+	encoder  *json.Encoder
+	sessions map[*Session]int
+	started  int
+}
+
+type jsonEvent struct {
+	Time    time.Time `json:"time"`
+	Session int       `json:"session"`
+	Player  string    `json:"player"`
+	Event   string    `json:"event"`
+	Data    Event     `json:"data"`
+}
+
+// JSONObserver writes every event to w as a line of JSON
+func JSONObserver(w io.Writer) Observer {
+	return &jsonObserver{
+		encoder:  json.NewEncoder(w),
+		sessions: make(map[*Session]int),
+	}
+}
+
+func (o *jsonObserver) Observe(s *Session, event Event) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if _, ok := event.(SolveStarted); ok {
+		o.started += 1
+		o.sessions[s] = o.started
+	}
+
+	line := jsonEvent{
+		Time:    time.Now(),
+		Session: o.sessions[s],
+		Player:  s.GetPlayerName(),
+		Event:   event.EventName(),
+		Data:    event,
+	}
+
+	if _, ok := event.(SolveFinished); ok {
+		delete(o.sessions, s)
+	}
+
+	if err := o.encoder.Encode(line); err != nil {
+		fmt.Println("Writing event :", err)
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"math"
 )
 
+func init() {
+	RegisterVerifierStrategy("optimistic", "Scores a verifier by the solutions its best answer eliminates", nil, fixed[VerifierStrategy](optimisticVerifierStrategy))
+	RegisterSolver("optimistic", "Top three codes with the optimistic verifier strategy", nil, noParams(Optimistic))
+}
+
 func Optimistic() *Solver {
 	return &Solver{
 		name:             "Optimistic",
@@ -12,9 +17,9 @@ func Optimistic() *Solver {
 	}
 }
 
-func optimisticVerifierStrategy(s *Solver, verifierIndex int, code []int) int {
-	trueSolutionCount := len(s.adjustSolutions(code, verifierIndex, true))
-	falseSolutionCount := len(s.adjustSolutions(code, verifierIndex, false))
+func optimisticVerifierStrategy(s *Session, verifierIndex int, code []int) int {
+	trueSolutionCount := s.adjustSolutions(code, verifierIndex, true).count()
+	falseSolutionCount := s.adjustSolutions(code, verifierIndex, false).count()
 	bestSolutionsCount := int(math.Min(float64(trueSolutionCount), float64(falseSolutionCount)))
 	if bestSolutionsCount == 0 {
 		return 0
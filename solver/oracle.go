@@ -0,0 +1,276 @@
+package solver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+// Cost is the number of codes tested and questions asked to find the secret code, ordered the same way as
+// AutoGame.Rank: fewer codes first, then fewer questions.
+type Cost struct {
+	Codes     int
+	Questions int
+}
+
+var unreachable = Cost{Codes: math.MaxInt32, Questions: math.MaxInt32}
+
+func (c Cost) Less(other Cost) bool {
+	if c.Codes != other.Codes {
+		return c.Codes < other.Codes
+	}
+
+	return c.Questions < other.Questions
+}
+
+func (c Cost) String() string {
+	return fmt.Sprintf("%v codes, %v questions", c.Codes, c.Questions)
+}
+
+func (c Cost) plus(codes, questions int) Cost {
+	if c == unreachable {
+		return c
+	}
+
+	return Cost{Codes: c.Codes + codes, Questions: c.Questions + questions}
+}
+
+func (c Cost) minus(codes, questions int) Cost {
+	return c.plus(-codes, -questions)
+}
+
+// OptimalPlay is the best possible performance on a set of verifier cards
+type OptimalPlay struct {
+	// WorstCase is the least cost that can be guaranteed whatever the secret is
+	WorstCase Cost
+
+	// Secret is the least cost for the secret the oracle was given, assuming the player is lucky enough to pick
+	// the questions that suit it best. No player can beat it on that secret. Only set when a secret was given.
+	Secret Cost
+}
+
+var ErrUnknownSecret = errors.New("secret is not a solution of the verifier cards")
+
+// Optimal computes by exhaustive search the least number of codes, then questions, needed to find the code of a
+// game with cards played by rules. When secret is not nil the least cost for that particular secret is computed as well.
+func Optimal(cards []*verifiers.VerifierCard, rules game.Rules, secret *game.Solution) (OptimalPlay, error) {
+	s := NotImplementedSolver().NewSession(game.NewInteractiveGame(cards))
+
+	// Testing a verifier twice in a round tells nothing new, so a round without a limit asks at most one question a card
+	questionsPerRound := len(cards)
+	if rules.QuestionsPerRound > 0 {
+		questionsPerRound = min(rules.QuestionsPerRound, len(cards))
+	}
+
+	result := OptimalPlay{
+		WorstCase: newOracle(s.space, questionsPerRound, -1).value(s.candidates, unreachable),
+	}
+
+	if secret != nil {
+		secretIndex := s.space.indexOf(*secret)
+		if secretIndex == -1 {
+			return result, ErrUnknownSecret
+		}
+
+		result.Secret = newOracle(s.space, questionsPerRound, secretIndex).value(s.candidates, unreachable)
+	}
+
+	return result, nil
+}
+
+type roundKey struct {
+	solutions     string
+	code          int
+	questionsLeft int
+}
+
+// bound is a memoized cost, exact costs are known, otherwise the cost is at least the bound's cost
+type bound struct {
+	cost  Cost
+	exact bool
+}
+
+// oracle searches every strategy for finding the code, memoizing the cost of each subset of solutions.
+// Searches are given a limit and stop looking once they know they can't beat it, the cost returned is exact when it
+// is less than the limit, otherwise it is a lower bound that is at least the limit.
+type oracle struct {
+	space *solutionSpace
+
+	// questionsPerRound is the most verifiers each code may be tested against
+	questionsPerRound int
+
+	// secret is the index of the solution that gives every answer, -1 to consider all answers
+	secret int
+
+	values map[string]bound
+	rounds map[roundKey]bound
+}
+
+func newOracle(space *solutionSpace, questionsPerRound int, secret int) *oracle {
+	return &oracle{
+		space:             space,
+		questionsPerRound: questionsPerRound,
+		secret:            secret,
+		values:            make(map[string]bound),
+		rounds:            make(map[roundKey]bound),
+	}
+}
+
+// value returns the least cost of finding the code when solutions are the candidates and no round is in progress
+func (o *oracle) value(solutions solutionSet, limit Cost) Cost {
+	codeCount := o.space.countCodes(solutions)
+	if codeCount <= 1 {
+		return Cost{}
+	}
+
+	key := setKey(solutions)
+	known, ok := o.values[key]
+	if ok && (known.exact || !known.cost.Less(limit)) {
+		return known.cost
+	}
+
+	// Each round asks at least one question, and has at most 2^questionsPerRound outcomes when the secret isn't known
+	minimumRounds := 1
+	if o.secret == -1 {
+		minimumRounds = max(1, int(math.Ceil(math.Log2(float64(codeCount))/float64(o.questionsPerRound))))
+	}
+	if lowerBound := (Cost{Codes: minimumRounds, Questions: minimumRounds}); !lowerBound.Less(limit) {
+		return lowerBound
+	}
+
+	best := unreachable
+	for _, codeIndex := range o.usefulCodes(solutions) {
+		cost := o.round(solutions, codeIndex, o.questionsPerRound, lesser(best, limit).minus(1, 0)).plus(1, 0)
+		if cost.Less(best) {
+			best = cost
+		}
+	}
+
+	o.values[key] = bound{cost: best, exact: best.Less(limit)}
+	return best
+}
+
+// round returns the least cost of finding the code when a round testing codeIndex has questionsLeft questions left
+func (o *oracle) round(solutions solutionSet, codeIndex int, questionsLeft int, limit Cost) Cost {
+	key := roundKey{solutions: setKey(solutions), code: codeIndex, questionsLeft: questionsLeft}
+	known, ok := o.rounds[key]
+	if ok && (known.exact || !known.cost.Less(limit)) {
+		return known.cost
+	}
+
+	best := unreachable
+	if questionsLeft < o.questionsPerRound {
+		// At least one question has been asked, the round can end here
+		best = o.value(solutions, limit)
+	}
+
+	if questionsLeft > 0 && best != (Cost{}) {
+		for _, verifierIndex := range o.usefulVerifiers(solutions, codeIndex) {
+			accepts := o.space.accepts[verifierIndex][codeIndex]
+			branchLimit := lesser(best, limit).minus(0, 1)
+			worst := Cost{}
+			for _, branch := range []solutionSet{solutions.and(accepts), solutions.andNot(accepts)} {
+				if o.secret != -1 && !branch.contains(o.secret) {
+					continue
+				}
+
+				if branchCost := o.round(branch, codeIndex, questionsLeft-1, branchLimit); worst.Less(branchCost) {
+					worst = branchCost
+				}
+
+				if !worst.Less(branchLimit) {
+					// The other branch can only make this verifier worse
+					break
+				}
+			}
+
+			if cost := worst.plus(0, 1); cost.Less(best) {
+				best = cost
+			}
+		}
+	}
+
+	o.rounds[key] = bound{cost: best, exact: best.Less(limit)}
+	return best
+}
+
+// usefulCodes returns codes that split the solutions for some verifier, best splits first so good limits are found
+// early. Codes that split the solutions the same way for every verifier are interchangeable, only one is returned.
+func (o *oracle) usefulCodes(solutions solutionSet) []int {
+	type candidate struct {
+		codeIndex int
+		balance   int
+	}
+
+	candidates := make([]candidate, 0, len(possibleCodes))
+	seen := make(map[string]bool)
+	for codeIndex := range possibleCodes {
+		signature := make([]byte, 0, len(o.space.accepts)*len(solutions)*8)
+		balance := 0
+		for verifierIndex := range o.space.accepts {
+			accepted := solutions.and(o.space.accepts[verifierIndex][codeIndex])
+			signature = append(signature, setKey(accepted)...)
+			balance = max(balance, min(accepted.count(), solutions.count()-accepted.count()))
+		}
+
+		if balance == 0 || seen[string(signature)] {
+			continue
+		}
+		seen[string(signature)] = true
+
+		candidates = append(candidates, candidate{codeIndex: codeIndex, balance: balance})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return b.balance - a.balance
+	})
+
+	codes := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		codes[i] = candidate.codeIndex
+	}
+
+	return codes
+}
+
+// usefulVerifiers returns the verifiers that split the solutions when testing the code, most even splits first
+func (o *oracle) usefulVerifiers(solutions solutionSet, codeIndex int) []int {
+	total := solutions.count()
+	balances := make([]int, len(o.space.accepts))
+	useful := make([]int, 0, len(o.space.accepts))
+	for verifierIndex := range o.space.accepts {
+		accepted := solutions.and(o.space.accepts[verifierIndex][codeIndex]).count()
+		balances[verifierIndex] = min(accepted, total-accepted)
+		if balances[verifierIndex] > 0 {
+			useful = append(useful, verifierIndex)
+		}
+	}
+
+	slices.SortStableFunc(useful, func(a, b int) int {
+		return balances[b] - balances[a]
+	})
+
+	return useful
+}
+
+func lesser(a, b Cost) Cost {
+	if a.Less(b) {
+		return a
+	}
+
+	return b
+}
+
+func setKey(set solutionSet) string {
+	key := make([]byte, 0, len(set)*8)
+	for _, word := range set {
+		key = binary.LittleEndian.AppendUint64(key, word)
+	}
+
+	return string(key)
+}
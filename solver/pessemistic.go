@@ -1,5 +1,10 @@
 package solver
 
+func init() {
+	RegisterVerifierStrategy("pessimistic", "Scores a verifier by the solutions its worst answer eliminates", nil, fixed[VerifierStrategy](pessimisticVerifierStrategy))
+	RegisterSolver("pessimistic", "Top three codes with the pessimistic verifier strategy", nil, noParams(Pessimistic))
+}
+
 func Pessimistic() *Solver {
 	return &Solver{
 		name:             "Pessimistic",
@@ -8,9 +13,9 @@ func Pessimistic() *Solver {
 	}
 }
 
-func pessimisticVerifierStrategy(s *Solver, verifierIndex int, code []int) int {
-	trueSolutionCount := len(s.adjustSolutions(code, verifierIndex, true))
-	falseSolutionCount := len(s.adjustSolutions(code, verifierIndex, false))
+func pessimisticVerifierStrategy(s *Session, verifierIndex int, code []int) int {
+	trueSolutionCount := s.adjustSolutions(code, verifierIndex, true).count()
+	falseSolutionCount := s.adjustSolutions(code, verifierIndex, false).count()
 	worstSolutionsCount := max(trueSolutionCount, falseSolutionCount)
 	if worstSolutionsCount == 0 {
 		return 0
@@ -1,15 +1,24 @@
 package solver
 
 import (
-	"fmt"
 	"slices"
 
-	"github.com/caseymerrill/turingsolver/game"
 	"github.com/caseymerrill/turingsolver/set"
 	"github.com/caseymerrill/turingsolver/verifiers"
 	"gonum.org/v1/gonum/stat/combin"
 )
 
+func init() {
+	RegisterCodeStrategy("combinator", "Scores a code by the worst case of asking every unsolved verifier", nil, fixed[CodeStrategy](pessimisticCombinatorCodeStrategy))
+	RegisterCodeStrategy("combinator1.1", "Scores a code by the worst case distinct codes of asking every unsolved verifier", nil, fixed[CodeStrategy](combinator1_1CodeStrategy))
+	RegisterVerifierStrategy("combinator1.1", "Scores a verifier by the worst case distinct codes of its answer", nil, fixed[VerifierStrategy](combinator1_1VerifierStrategy))
+	RegisterCombinedStrategy("combinator2", "Plans the order of unsolved verifiers to minimize the worst case distinct codes", nil, fixed[CombinedStrategy](combinator2))
+	RegisterSolver("pc", "Combinator codes with the pessimistic verifier strategy", nil, noParams(Combinator))
+	RegisterSolver("pc1.1", "Combinator1.1 codes and verifiers", nil, noParams(Combinator1_1))
+	RegisterSolver("best", "The best solver, currently pc1.1", nil, noParams(Combinator1_1))
+	RegisterSolver("pc2", "Combinator2 planned rounds", nil, noParams(Combinator2))
+}
+
 func Combinator() *Solver {
 	return &Solver{
 		name:             "Combinator",
@@ -27,13 +36,13 @@ func Combinator1_1() *Solver {
 }
 
 func Combinator2() *Solver {
-	panic("Not implemented")
 	return &Solver{
-		name: "Combinator2",
+		name:             "Combinator2",
+		combinedStrategy: combinator2,
 	}
 }
 
-func pessimisticCombinatorCodeStrategy(s *Solver, code []int) int {
+func pessimisticCombinatorCodeStrategy(s *Session, code []int) int {
 	neededVerifiers := unsolvedVerifiers(s)
 	choose := min(3, len(neededVerifiers))
 	combinations := combin.Combinations(len(neededVerifiers), choose)
@@ -41,7 +50,7 @@ func pessimisticCombinatorCodeStrategy(s *Solver, code []int) int {
 	for _, combination := range combinations {
 		score := 0
 		for _, neededVerifierIndex := range combination {
-			score += s.verifierStrategy(s, neededVerifiers[neededVerifierIndex], code)
+			score += s.solver.verifierStrategy(s, neededVerifiers[neededVerifierIndex], code)
 		}
 
 		if score > bestScore {
@@ -52,13 +61,13 @@ func pessimisticCombinatorCodeStrategy(s *Solver, code []int) int {
 	return bestScore
 }
 
-func combinator1_1CodeStrategy(s *Solver, code []int) int {
+func combinator1_1CodeStrategy(s *Session, code []int) int {
 	combinations := combin.Combinations(len(s.game.GetVerifierCards()), 3)
 	bestScore := 0
 	for _, combination := range combinations {
 		score := 0
 		for _, neededVerifierIndex := range combination {
-			score += s.verifierStrategy(s, neededVerifierIndex, code)
+			score += s.solver.verifierStrategy(s, neededVerifierIndex, code)
 		}
 
 		if score > bestScore {
@@ -69,119 +78,93 @@ func combinator1_1CodeStrategy(s *Solver, code []int) int {
 	return bestScore
 }
 
-func scorer(solutions []game.Solution) int {
-	solutionCount := len(solutions)
-	codeCount := countCodes(solutions)
+func scorer(s *Session, solutions solutionSet) int {
+	solutionCount := solutions.count()
+	codeCount := s.countCodes(solutions)
 
 	return codeCount*1_000_000 + solutionCount
 }
 
-func combinator1_1VerifierStrategy(s *Solver, verifierIndex int, code []int) int {
-	trueScore := scorer(s.adjustSolutions(code, verifierIndex, true))
-	falseScore := scorer(s.adjustSolutions(code, verifierIndex, false))
+func combinator1_1VerifierStrategy(s *Session, verifierIndex int, code []int) int {
+	trueScore := scorer(s, s.adjustSolutions(code, verifierIndex, true))
+	falseScore := scorer(s, s.adjustSolutions(code, verifierIndex, false))
 
 	worstScore := max(trueScore, falseScore)
 	if worstScore == 0 {
 		return 0
 	}
 
-	return scorer(s.solutions) - worstScore
+	return scorer(s, s.candidates) - worstScore
 }
 
-func combinator2(s *Solver, code []int) (int, []int) {
-	type possibility struct {
-		score            int
-		possibleCodes    set.Set[string]
-		verifiersIndexes []int
-	}
-
-	// currentSolutionCodeCount := countCodes(s.solutions)
-	currentCodeCount := countCodes(s.solutions)
+// combinator2 returns the order of unsolved verifiers to ask about code whose worst-case outcome leaves the fewest
+// codes, along with how many codes that worst case eliminates.
+func combinator2(s *Session, code []int) (int, []int) {
+	currentCodeCount := s.countCodes(s.candidates)
 	neededVerifiers := unsolvedVerifiers(s)
-	choose := min(3, len(neededVerifiers))
-	combinations := combin.Permutations(len(neededVerifiers), choose)
-	var bestWorstCase possibility
-	for _, combination := range combinations {
-		resultsForThisCombination := make([]possibility, 0)
-		for _, neededVerifierIndex := range combination {
+	choose := min(s.questionsPerRound, len(neededVerifiers))
+	permutations := combin.Permutations(len(neededVerifiers), choose)
+	bestWorstCase := -1
+	var bestPlan []int
+	for _, permutation := range permutations {
+		plan := make([]int, len(permutation))
+		outcomes := []solutionSet{s.candidates}
+		for i, neededVerifierIndex := range permutation {
 			verifierIndex := neededVerifiers[neededVerifierIndex]
-			trueSolutions := s.adjustSolutions(code, verifierIndex, true)
-			trueCodes := solutions2CodeSet(trueSolutions)
-
-			falseSolutions := s.adjustSolutions(code, verifierIndex, false)
-			falseCodes := solutions2CodeSet(falseSolutions)
+			plan[i] = verifierIndex
 
-			if len(resultsForThisCombination) == 0 {
-				if len(trueCodes) > 0 {
-					resultsForThisCombination = append(resultsForThisCombination, possibility{possibleCodes: trueCodes, verifiersIndexes: []int{verifierIndex}})
+			nextOutcomes := make([]solutionSet, 0, len(outcomes)*2)
+			for _, outcome := range outcomes {
+				if s.countCodes(outcome) == 1 {
+					nextOutcomes = append(nextOutcomes, outcome)
+					continue
 				}
 
-				if len(falseCodes) > 0 {
-					resultsForThisCombination = append(resultsForThisCombination, possibility{possibleCodes: falseCodes, verifiersIndexes: []int{verifierIndex}})
+				// Verifiers that don't split an outcome are skipped when the plan is run, so the outcome is kept as is
+				if ifTrue := s.filterSolutions(outcome, code, verifierIndex, true); !ifTrue.isEmpty() {
+					nextOutcomes = append(nextOutcomes, ifTrue)
 				}
-			} else {
-				newResultPermutations := make([]possibility, 0)
-				for _, resultPermutation := range resultsForThisCombination {
-					if len(resultPermutation.possibleCodes) == 1 {
-						continue
-					}
-
-					ifTrue := resultPermutation.possibleCodes.Intersection(trueCodes)
-					if len(ifTrue) > 0 {
-						newResultPermutations = append(newResultPermutations, possibility{possibleCodes: ifTrue, verifiersIndexes: append(resultPermutation.verifiersIndexes, verifierIndex)})
-					}
-
-					ifFalse := resultPermutation.possibleCodes.Intersection(falseCodes)
-					if len(ifFalse) > 0 {
-						newResultPermutations = append(newResultPermutations, possibility{possibleCodes: ifFalse, verifiersIndexes: append(resultPermutation.verifiersIndexes, verifierIndex)})
-					}
-
-					// Keep old possibility even if the next verifier isn't useful
-					if len(ifTrue) == 0 && len(ifFalse) == 0 {
-						newResultPermutations = append(newResultPermutations, resultPermutation)
-					}
+
+				if ifFalse := s.filterSolutions(outcome, code, verifierIndex, false); !ifFalse.isEmpty() {
+					nextOutcomes = append(nextOutcomes, ifFalse)
 				}
-				resultsForThisCombination = newResultPermutations
 			}
+			outcomes = nextOutcomes
 		}
 
-		if len(resultsForThisCombination) == 0 {
-			continue
+		worstCaseThisPermutation := 0
+		for _, outcome := range outcomes {
+			worstCaseThisPermutation = max(worstCaseThisPermutation, s.countCodes(outcome))
 		}
 
-		worstCaseThisCombination := slices.MinFunc(resultsForThisCombination, func(s1, s2 possibility) int {
-			return len(s1.possibleCodes) - len(s2.possibleCodes)
-		})
-
-		if len(bestWorstCase.possibleCodes) == 0 || len(worstCaseThisCombination.possibleCodes) < len(bestWorstCase.possibleCodes) {
-			bestWorstCase = worstCaseThisCombination
+		if bestWorstCase == -1 || worstCaseThisPermutation < bestWorstCase {
+			bestWorstCase = worstCaseThisPermutation
+			bestPlan = plan
 		}
-
 	}
 
-	if len(bestWorstCase.possibleCodes) == 0 {
+	if bestWorstCase == -1 {
 		return -1, nil
 	}
 
-	score := currentCodeCount - len(bestWorstCase.possibleCodes)
-	//fmt.Printf("%v %v %+v\n", code, score, bestWorstCase)
-	return score, bestWorstCase.verifiersIndexes
+	return currentCodeCount - bestWorstCase, bestPlan
 }
 
-func unsolvedVerifiers(s *Solver) []int {
-	codes := set.Set[string]{}
+// unsolvedVerifiers returns the indexes of verifiers that are not the same across all candidate codes
+func unsolvedVerifiers(s *Session) []int {
+	var codes codeMask
 	unsolved := set.Set[int]{}
-	firstVerifier := make([]*verifiers.Verifier, len(s.solutions[0].Verifiers))
-	for _, solution := range s.solutions {
+	firstVerifier := make([]*verifiers.Verifier, len(s.game.GetVerifierCards()))
+	for _, solutionIndex := range s.candidates.indexes() {
 		// Skip duplicate codes... don't care about solving the verifiers for the same code
-		solutionCode := code2str(solution.Code)
-		if codes.Contains(solutionCode) {
+		solutionCode := s.space.codes[solutionIndex]
+		if codes.has(solutionCode) {
 			continue
 		} else {
-			codes.Add(solutionCode)
+			codes.set(solutionCode)
 		}
 
-		for verifierIndex, verifier := range solution.Verifiers {
+		for verifierIndex, verifier := range s.space.solutions[solutionIndex].Verifiers {
 			if firstVerifier[verifierIndex] == nil {
 				firstVerifier[verifierIndex] = verifier
 			} else if verifier != firstVerifier[verifierIndex] {
@@ -194,24 +177,3 @@ func unsolvedVerifiers(s *Solver) []int {
 	slices.Sort(asSlice)
 	return asSlice
 }
-
-func code2str(code []int) string {
-	codeStr := ""
-	for _, c := range code {
-		codeStr += fmt.Sprintf("%v", c)
-	}
-	return codeStr
-}
-
-func solutions2CodeSet(solutions []game.Solution) set.Set[string] {
-	codes := set.Set[string]{}
-	for _, solution := range solutions {
-		codes.Add(code2str(solution.Code))
-	}
-
-	return codes
-}
-
-func countCodes(solutions []game.Solution) int {
-	return len(solutions2CodeSet(solutions))
-}
@@ -2,6 +2,12 @@ package solver
 
 import "math/rand"
 
+func init() {
+	RegisterCodeStrategy("random", "Picks any code that some verifier can split", nil, fixed[CodeStrategy](randomCodeStrategy))
+	RegisterVerifierStrategy("random", "Picks any verifier that splits the solutions", nil, fixed[VerifierStrategy](randomVerifierStrategy))
+	RegisterSolver("random", "Random codes and verifiers, as long as they are useful", nil, noParams(Random))
+}
+
 func Random() *Solver {
 	return &Solver{
 		name:             "Random",
@@ -10,9 +16,9 @@ func Random() *Solver {
 	}
 }
 
-func randomCodeStrategy(s *Solver, code []int) int {
+func randomCodeStrategy(s *Session, code []int) int {
 	for i := range s.game.GetVerifierCards() {
-		score := s.verifierStrategy(s, i, code)
+		score := s.solver.verifierStrategy(s, i, code)
 		if score > 0 {
 			return rand.Intn(100) + 1
 		}
@@ -22,9 +28,9 @@ func randomCodeStrategy(s *Solver, code []int) int {
 	return 0
 }
 
-func randomVerifierStrategy(s *Solver, verifierIndex int, code []int) int {
-	trueSolutionCount := len(s.adjustSolutions(code, verifierIndex, true))
-	falseSolutionCount := len(s.adjustSolutions(code, verifierIndex, false))
+func randomVerifierStrategy(s *Session, verifierIndex int, code []int) int {
+	trueSolutionCount := s.adjustSolutions(code, verifierIndex, true).count()
+	falseSolutionCount := s.adjustSolutions(code, verifierIndex, false).count()
 	if (trueSolutionCount > 0 && trueSolutionCount < len(s.solutions)) ||
 		(falseSolutionCount > 0 && falseSolutionCount < len(s.solutions)) {
 		return rand.Intn(100) + 1
@@ -0,0 +1,405 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Params are the parameters given to a strategy or solver in a spec, keyed by lowercase name
+type Params map[string]string
+
+// Int returns the named parameter as an int, or defaultValue if it wasn't given
+func (p Params) Int(name string, defaultValue int) (int, error) {
+	value, ok := p[strings.ToLower(name)]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %v must be a number : %w", name, err)
+	}
+
+	return intValue, nil
+}
+
+// String returns the named parameter, or defaultValue if it wasn't given
+func (p Params) String(name string, defaultValue string) string {
+	if value, ok := p[strings.ToLower(name)]; ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Param describes a parameter accepted by a registered strategy or solver
+type Param struct {
+	Name        string
+	Description string
+	Default     string
+}
+
+// Registration describes a registered strategy or solver
+type Registration struct {
+	Name        string
+	Description string
+	Params      []Param
+}
+
+type CodeStrategyFactory func(Params) (CodeStrategy, error)
+type VerifierStrategyFactory func(Params) (VerifierStrategy, error)
+type CombinedStrategyFactory func(Params) (CombinedStrategy, error)
+type SolverFactory func(Params) (*Solver, error)
+
+type registered[T any] struct {
+	Registration
+	factory T
+}
+
+// registry holds everything that can be named in a solver spec
+type registry struct {
+	lock               sync.RWMutex
+	codeStrategies     map[string]registered[CodeStrategyFactory]
+	verifierStrategies map[string]registered[VerifierStrategyFactory]
+	combinedStrategies map[string]registered[CombinedStrategyFactory]
+	solvers            map[string]registered[SolverFactory]
+}
+
+var defaultRegistry = &registry{
+	codeStrategies:     make(map[string]registered[CodeStrategyFactory]),
+	verifierStrategies: make(map[string]registered[VerifierStrategyFactory]),
+	combinedStrategies: make(map[string]registered[CombinedStrategyFactory]),
+	solvers:            make(map[string]registered[SolverFactory]),
+}
+
+// solverParams are accepted by every solver, whether it is registered or composed
+var solverParams = []Param{
+	{Name: "name", Description: "Player name of the solver"},
+	{Name: "maxCodes", Description: "Codes to test before giving up, 0 for no limit", Default: strconv.Itoa(DefaultBudget.Codes)},
+	{Name: "maxQuestions", Description: "Questions to ask before giving up, 0 for no limit", Default: strconv.Itoa(DefaultBudget.Questions)},
+	{Name: "noise", Description: "Handling of wrong answers: off, reask or plausible", Default: NoiseOff.String()},
+}
+
+var ErrUnknownName = errors.New("unknown name")
+
+// RegisterCodeStrategy makes a code strategy available to solver specs as code=name
+func RegisterCodeStrategy(name, description string, params []Param, factory CodeStrategyFactory) {
+	register(defaultRegistry.codeStrategies, name, description, params, factory)
+}
+
+// RegisterVerifierStrategy makes a verifier strategy available to solver specs as verifier=name
+func RegisterVerifierStrategy(name, description string, params []Param, factory VerifierStrategyFactory) {
+	register(defaultRegistry.verifierStrategies, name, description, params, factory)
+}
+
+// RegisterCombinedStrategy makes a combined strategy available to solver specs as combined=name
+func RegisterCombinedStrategy(name, description string, params []Param, factory CombinedStrategyFactory) {
+	register(defaultRegistry.combinedStrategies, name, description, params, factory)
+}
+
+// RegisterSolver makes a solver available by name, the params accepted by every solver don't need to be listed
+func RegisterSolver(name, description string, params []Param, factory SolverFactory) {
+	register(defaultRegistry.solvers, name, description, params, factory)
+}
+
+func register[T any](registrations map[string]registered[T], name, description string, params []Param, factory T) {
+	defaultRegistry.lock.Lock()
+	defer defaultRegistry.lock.Unlock()
+
+	key := strings.ToLower(name)
+	if _, exists := registrations[key]; exists {
+		panic("Registered twice: " + name)
+	}
+
+	registrations[key] = registered[T]{
+		Registration: Registration{Name: name, Description: description, Params: params},
+		factory:      factory,
+	}
+}
+
+func lookup[T any](registrations map[string]registered[T], kind string, name string, params Params) (T, error) {
+	defaultRegistry.lock.RLock()
+	defer defaultRegistry.lock.RUnlock()
+
+	found, ok := registrations[strings.ToLower(name)]
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %v %v", ErrUnknownName, kind, name)
+	}
+
+	if err := checkParams(params, found.Params); err != nil {
+		var zero T
+		return zero, fmt.Errorf("%v %v : %w", kind, name, err)
+	}
+
+	return found.factory, nil
+}
+
+// checkParams returns an error if params has anything not in accepted
+func checkParams(params Params, accepted []Param) error {
+	for name := range params {
+		if !slices.ContainsFunc(accepted, func(param Param) bool { return strings.EqualFold(param.Name, name) }) {
+			return fmt.Errorf("unknown parameter: %v", name)
+		}
+	}
+
+	return nil
+}
+
+func registrations[T any](registered map[string]registered[T]) []Registration {
+	defaultRegistry.lock.RLock()
+	defer defaultRegistry.lock.RUnlock()
+
+	result := make([]Registration, 0, len(registered))
+	for _, r := range registered {
+		result = append(result, r.Registration)
+	}
+
+	slices.SortFunc(result, func(a, b Registration) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return result
+}
+
+// Solvers returns every registered solver, sorted by name
+func Solvers() []Registration {
+	return registrations(defaultRegistry.solvers)
+}
+
+// CodeStrategies returns every registered code strategy, sorted by name
+func CodeStrategies() []Registration {
+	return registrations(defaultRegistry.codeStrategies)
+}
+
+// VerifierStrategies returns every registered verifier strategy, sorted by name
+func VerifierStrategies() []Registration {
+	return registrations(defaultRegistry.verifierStrategies)
+}
+
+// CombinedStrategies returns every registered combined strategy, sorted by name
+func CombinedStrategies() []Registration {
+	return registrations(defaultRegistry.combinedStrategies)
+}
+
+// SolverParams returns the params accepted by every solver
+func SolverParams() []Param {
+	return slices.Clone(solverParams)
+}
+
+// Parse creates a solver from a spec. A spec is either a registered solver name with optional params, e.g.
+// "pc1.1(maxCodes=20)", or strategies composed with params, e.g. "code=topthree(n=2),verifier=pessimistic,name=MyBot".
+func Parse(spec string) (*Solver, error) {
+	name, params, err := parseCall(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(name, "=") {
+		return parseComposition(spec)
+	}
+
+	solverOptions := Params{}
+	specificParams := Params{}
+	for key, value := range params {
+		if slices.ContainsFunc(solverParams, func(param Param) bool { return strings.EqualFold(param.Name, key) }) {
+			solverOptions[key] = value
+		} else {
+			specificParams[key] = value
+		}
+	}
+
+	factory, err := lookup(defaultRegistry.solvers, "solver", name, specificParams)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := factory(specificParams)
+	if err != nil {
+		return nil, fmt.Errorf("solver %v : %w", name, err)
+	}
+
+	return applySolverParams(s, solverOptions)
+}
+
+func parseComposition(spec string) (*Solver, error) {
+	parts, err := splitTopLevel(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	options := Params{}
+	s := &Solver{}
+	var names []string
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value: %v", part)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		strategyName, strategyParams, err := parseCall(value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "code":
+			factory, err := lookup(defaultRegistry.codeStrategies, "code strategy", strategyName, strategyParams)
+			if err != nil {
+				return nil, err
+			} else if s.codeStrategy, err = factory(strategyParams); err != nil {
+				return nil, fmt.Errorf("code strategy %v : %w", strategyName, err)
+			}
+		case "verifier":
+			factory, err := lookup(defaultRegistry.verifierStrategies, "verifier strategy", strategyName, strategyParams)
+			if err != nil {
+				return nil, err
+			} else if s.verifierStrategy, err = factory(strategyParams); err != nil {
+				return nil, fmt.Errorf("verifier strategy %v : %w", strategyName, err)
+			}
+		case "combined":
+			factory, err := lookup(defaultRegistry.combinedStrategies, "combined strategy", strategyName, strategyParams)
+			if err != nil {
+				return nil, err
+			} else if s.combinedStrategy, err = factory(strategyParams); err != nil {
+				return nil, fmt.Errorf("combined strategy %v : %w", strategyName, err)
+			}
+		default:
+			if err := checkParams(Params{key: value}, solverParams); err != nil {
+				return nil, err
+			}
+
+			options[key] = strings.TrimSpace(value)
+			continue
+		}
+
+		names = append(names, strategyName)
+	}
+
+	if s.combinedStrategy != nil && (s.codeStrategy != nil || s.verifierStrategy != nil) {
+		return nil, errors.New("combined strategy can't be used with code or verifier strategies")
+	} else if s.combinedStrategy == nil && (s.codeStrategy == nil || s.verifierStrategy == nil) {
+		return nil, errors.New("both code and verifier strategies are needed")
+	}
+
+	s.name = strings.Join(names, "+")
+	return applySolverParams(s, options)
+}
+
+// applySolverParams returns the solver configured with the params accepted by every solver
+func applySolverParams(s *Solver, params Params) (*Solver, error) {
+	if name := params.String("name", ""); name != "" {
+		named := *s
+		named.name = name
+		s = &named
+	}
+
+	_, hasCodes := params["maxcodes"]
+	_, hasQuestions := params["maxquestions"]
+	if hasCodes || hasQuestions {
+		budget := s.getBudget()
+		var err error
+		if budget.Codes, err = params.Int("maxCodes", budget.Codes); err != nil {
+			return nil, err
+		} else if budget.Questions, err = params.Int("maxQuestions", budget.Questions); err != nil {
+			return nil, err
+		}
+
+		s = s.WithBudget(budget)
+	}
+
+	if noise, ok := params["noise"]; ok {
+		mode, err := ParseNoiseMode(noise)
+		if err != nil {
+			return nil, err
+		}
+
+		s = s.WithNoise(mode)
+	}
+
+	return s, nil
+}
+
+// parseCall splits "name(key=value,...)" into its name and params, anything without parentheses is just a name
+func parseCall(spec string) (string, Params, error) {
+	spec = strings.TrimSpace(spec)
+	open := strings.Index(spec, "(")
+	if open == -1 || strings.Contains(spec[:open], "=") {
+		return spec, Params{}, nil
+	} else if !strings.HasSuffix(spec, ")") {
+		return "", nil, fmt.Errorf("missing closing parenthesis: %v", spec)
+	}
+
+	params := Params{}
+	arguments, err := splitTopLevel(spec[open+1 : len(spec)-1])
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, argument := range arguments {
+		key, value, ok := strings.Cut(argument, "=")
+		if !ok {
+			return "", nil, fmt.Errorf("expected key=value: %v", argument)
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+
+	return strings.TrimSpace(spec[:open]), params, nil
+}
+
+// splitTopLevel splits on commas that are not inside parentheses, empty parts are dropped
+func splitTopLevel(spec string) ([]string, error) {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range spec {
+		switch r {
+		case '(':
+			depth += 1
+		case ')':
+			depth -= 1
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses: %v", spec)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses: %v", spec)
+	}
+	parts = append(parts, spec[start:])
+
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			nonEmpty = append(nonEmpty, strings.TrimSpace(part))
+		}
+	}
+
+	return nonEmpty, nil
+}
+
+// noParams adapts a solver constructor to a SolverFactory
+func noParams(constructor func() *Solver) SolverFactory {
+	return func(Params) (*Solver, error) {
+		return constructor(), nil
+	}
+}
+
+// fixed adapts a strategy without params to a factory
+func fixed[T any](strategy T) func(Params) (T, error) {
+	return func(Params) (T, error) {
+		return strategy, nil
+	}
+}
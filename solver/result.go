@@ -0,0 +1,51 @@
+package solver
+
+import (
+	"errors"
+
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+var (
+	// ErrNoSolutions is returned when the verifier cards don't have any solutions
+	ErrNoSolutions = errors.New("no solutions found")
+	// ErrContradictoryAnswers is returned when the answers given rule out every solution
+	ErrContradictoryAnswers = errors.New("answers contradict every solution")
+	// ErrBudgetExhausted is returned when the code or question budget runs out before the code is found
+	ErrBudgetExhausted = errors.New("budget exhausted")
+)
+
+// GameError is returned when the game fails to answer
+type GameError struct {
+	Op  string
+	Err error
+}
+
+func (e *GameError) Error() string {
+	return e.Op + " : " + e.Err.Error()
+}
+
+func (e *GameError) Unwrap() error {
+	return e.Err
+}
+
+// Budget limits the moves a solver makes before giving up, zero is unlimited
+type Budget struct {
+	Codes     int
+	Questions int
+}
+
+var DefaultBudget = Budget{Codes: 100}
+
+// Result of solving a game
+type Result struct {
+	// Correct is whether the guess was right
+	Correct bool
+
+	// Code that was guessed, and the verifiers that were found
+	Code      []int
+	Verifiers []*verifiers.Verifier
+
+	CodesTested    int
+	QuestionsAsked int
+}
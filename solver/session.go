@@ -0,0 +1,356 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/caseymerrill/turingsolver/game"
+)
+
+// Session is a Solver playing a single game, it is the player the game sees
+type Session struct {
+	solver *Solver
+	game   game.Game
+
+	// verifiersTestedThisCode is the number of verifiers tested for the current code, at most questionsPerRound
+	verifiersTestedThisCode int
+	questionsPerRound       int
+
+	// space indexes all initial solutions of the game, candidates is the subset still possible
+	space      *solutionSpace
+	candidates solutionSet
+
+	// solutions are the candidates as a slice
+	solutions []game.Solution
+
+	// answers are every answer the game has given, in order
+	answers []game.Answer
+
+	// wrongAnswers is the number of answers even the most plausible solutions contradict
+	wrongAnswers int
+
+	// plan is the rest of the verifiers to ask about the current code, when they were chosen with the code either by a
+	// combined strategy or because they are being asked again
+	plan     []int
+	reasking bool
+}
+
+// NewSession starts playing the game, with every solution of the game as a candidate
+func (s *Solver) NewSession(gameToSolve game.Game) *Session {
+	session := &Session{
+		solver: s,
+		game:   gameToSolve,
+		space:  newSolutionSpace(gameToSolve.GetVerifierCards(), InitialSolutions(gameToSolve)),
+	}
+	session.setCandidates(session.space.all())
+
+	// Games that don't limit questions get as many as the strategies plan for
+	if limit := gameToSolve.Rules().QuestionsPerRound; limit > 0 && limit < questionsPerCode {
+		session.questionsPerRound = limit
+	} else {
+		session.questionsPerRound = questionsPerCode
+	}
+
+	return session
+}
+
+func (s *Session) GetPlayerName() string {
+	return s.solver.GetPlayerName()
+}
+
+// Solutions returns the solutions that are still possible
+func (s *Session) Solutions() []game.Solution {
+	return s.solutions
+}
+
+// Answers returns every answer the game has given so far
+func (s *Session) Answers() []game.Answer {
+	return slices.Clone(s.answers)
+}
+
+// Solve plays the game until it finds the code, the context is checked before every move.
+// A session with answers applied continues the round in progress. The result has the moves made so far by Solve, even
+// when an error is returned.
+func (s *Session) Solve(ctx context.Context) (Result, error) {
+	s.emit(SolveStarted{Player: s.GetPlayerName(), Cards: len(s.game.GetVerifierCards())})
+	result, err := s.solve(ctx)
+	s.emit(SolveFinished{Result: result, Err: err})
+
+	return result, err
+}
+
+func (s *Session) solve(ctx context.Context) (Result, error) {
+	result := Result{}
+	budget := s.solver.getBudget()
+	s.emit(InitialSolutionsFound{Solutions: len(s.solutions), Codes: s.countCodes(s.candidates)})
+	if len(s.solutions) == 0 {
+		return result, ErrNoSolutions
+	}
+
+	// A round left unfinished by Apply is played before choosing another code
+	resuming := len(s.answers) > 0 && s.verifiersTestedThisCode > 0 && s.verifiersTestedThisCode < s.questionsPerRound
+	for !s.solved() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		} else if budget.Codes > 0 && result.CodesTested >= budget.Codes {
+			return result, fmt.Errorf("%w: tested %v codes", ErrBudgetExhausted, result.CodesTested)
+		}
+
+		var code []int
+		var reasking bool
+		if resuming {
+			code, reasking = s.answers[len(s.answers)-1].Code, s.reasking
+			resuming = false
+		} else {
+			// Answers rivals of the solution disagree with are asked again before guessing
+			var score int
+			var plan []int
+			var runnersUp []Alternative
+			code, plan = s.nextReask()
+			reasking = code != nil
+			if reasking {
+				score = len(plan)
+			} else if s.solver.combinedStrategy != nil {
+				code, score, plan, runnersUp = s.selectCodeAndPlan()
+			} else {
+				code, score, runnersUp = s.selectCode()
+			}
+
+			codeSelected := CodeSelected{Code: code, Score: score, Plan: plan}
+			if s.solver.explain {
+				codeSelected.Explanation = s.explainCode(code, runnersUp, reasking)
+			}
+			s.emit(codeSelected)
+			s.plan, s.reasking = plan, reasking
+
+			result.CodesTested += 1
+			s.verifiersTestedThisCode = 0
+		}
+
+		if err := s.game.StartRound(s, code); err != nil {
+			return result, &GameError{Op: "starting round", Err: err}
+		}
+
+		for s.verifiersTestedThisCode < s.questionsPerRound {
+			var verifier, score int
+			var runnersUp []Alternative
+			if reasking && len(s.plan) == 0 {
+				break
+			} else if reasking {
+				verifier, s.plan = s.plan[0], s.plan[1:]
+			} else if s.solver.combinedStrategy != nil {
+				verifier, s.plan = s.selectPlannedVerifier(code, s.plan)
+			} else {
+				verifier, score, runnersUp = s.selectVerifier(code)
+			}
+
+			if verifier == -1 {
+				s.emit(NoUsefulVerifier{Code: code})
+				break
+			}
+
+			verifierSelected := VerifierSelected{
+				Code:     code,
+				Verifier: verifier,
+				Score:    score,
+				IfTrue:   s.adjustSolutions(code, verifier, true).count(),
+				IfFalse:  s.adjustSolutions(code, verifier, false).count(),
+			}
+			if s.solver.explain {
+				verifierSelected.Explanation = s.explainVerifier(code, verifier, runnersUp, reasking)
+			}
+			s.emit(verifierSelected)
+
+			if err := ctx.Err(); err != nil {
+				return result, err
+			} else if budget.Questions > 0 && result.QuestionsAsked >= budget.Questions {
+				return result, fmt.Errorf("%w: asked %v questions", ErrBudgetExhausted, result.QuestionsAsked)
+			}
+
+			valid, err := s.game.AskQuestion(s, code, verifier)
+			if err != nil {
+				return result, &GameError{Op: "asking question", Err: err}
+			}
+
+			result.QuestionsAsked += 1
+			s.verifiersTestedThisCode += 1
+			if err := s.record(game.Answer{Code: code, Verifier: verifier, Valid: valid}); err != nil {
+				return result, err
+			} else if !reasking && s.solved() {
+				break
+			}
+		}
+
+		if err := s.game.EndRound(s); err != nil {
+			return result, &GameError{Op: "ending round", Err: err}
+		}
+	}
+
+	result.Code = s.solutions[0].Code
+	result.Verifiers = s.solutions[0].Verifiers
+	correct, err := s.game.MakeGuess(s, result.Code)
+	if err != nil {
+		return result, &GameError{Op: "making guess", Err: err}
+	}
+	result.Correct = correct
+	s.emit(GuessMade{Code: result.Code, Verifiers: result.Verifiers, Correct: result.Correct})
+
+	return result, nil
+}
+
+// record updates the candidates with an answer from the game
+func (s *Session) record(answer game.Answer) error {
+	s.answers = append(s.answers, answer)
+	s.emit(AnswerReceived{Code: answer.Code, Verifier: answer.Verifier, Valid: answer.Valid})
+
+	before := len(s.solutions)
+	if s.solver.noise == NoiseOff {
+		s.setCandidates(s.adjustSolutions(answer.Code, answer.Verifier, answer.Valid))
+	} else if contradiction, detected := s.updatePlausible(); detected {
+		s.emit(contradiction)
+	}
+	s.emit(SolutionsEliminated{Eliminated: max(0, before-len(s.solutions)), Remaining: len(s.solutions), Codes: s.countCodes(s.candidates)})
+
+	if len(s.solutions) == 0 {
+		return fmt.Errorf("%w: code %v verifier %v answered %v", ErrContradictoryAnswers, answer.Code, answer.Verifier+1, answer.Valid)
+	}
+
+	return nil
+}
+
+// emit tells every observer of the solver about the event
+func (s *Session) emit(event Event) {
+	for _, observer := range s.solver.observers {
+		observer.Observe(s, event)
+	}
+}
+
+// hasSolution returns true if all possible solutions use the same code
+func (s *Session) hasSolution() bool {
+	if len(s.solutions) == 0 {
+		return false
+	}
+
+	code := s.solutions[0].Code
+	for _, solution := range s.solutions[1:] {
+		for i, c := range code {
+			if c != solution.Code[i] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// selectCode returns the best code according to the code strategy and its score, with the runners up when explaining
+func (s *Session) selectCode() ([]int, int, []Alternative) {
+	bestScore := -1
+	var bestCode []int
+	ranking := ranking{enabled: s.solver.explain}
+	for _, code := range possibleCodes {
+		var score int
+		score = s.solver.codeStrategy(s, code)
+		ranking.add(Alternative{Code: code, Verifier: -1, Score: score})
+
+		if score > bestScore {
+			bestScore = score
+			bestCode = code
+		}
+	}
+
+	return bestCode, bestScore, ranking.runnersUp()
+}
+
+// selectCodeAndPlan returns the best code according to the combined strategy along with its score and verifier plan,
+// and the runners up when explaining
+func (s *Session) selectCodeAndPlan() ([]int, int, []int, []Alternative) {
+	bestScore := -1
+	var bestCode, bestPlan []int
+	ranking := ranking{enabled: s.solver.explain}
+	for _, code := range possibleCodes {
+		score, plan := s.solver.combinedStrategy(s, code)
+		ranking.add(Alternative{Code: code, Verifier: -1, Score: score})
+		if score > bestScore {
+			bestScore = score
+			bestCode = code
+			bestPlan = plan
+		}
+	}
+
+	return bestCode, bestScore, bestPlan, ranking.runnersUp()
+}
+
+// askedThisRound returns true if the verifier was tested in the current round, the rules don't allow asking again even
+// when the answer may have been wrong
+func (s *Session) askedThisRound(verifierIndex int) bool {
+	for _, answer := range s.answers[len(s.answers)-s.verifiersTestedThisCode:] {
+		if answer.Verifier == verifierIndex {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectPlannedVerifier returns the next verifier in plan that is still useful, and the rest of the plan.
+// Returns -1 when nothing useful is left in the plan.
+func (s *Session) selectPlannedVerifier(code []int, plan []int) (int, []int) {
+	for len(plan) > 0 {
+		verifier := plan[0]
+		plan = plan[1:]
+		if s.askedThisRound(verifier) {
+			continue
+		} else if !s.adjustSolutions(code, verifier, true).isEmpty() && !s.adjustSolutions(code, verifier, false).isEmpty() {
+			return verifier, plan
+		}
+	}
+
+	return -1, plan
+}
+
+// selectVerifier returns the best verifier to test code against and its score, -1 if none are useful.
+// The runners up are returned when explaining.
+func (s *Session) selectVerifier(code []int) (int, int, []Alternative) {
+	bestVerifierIndex := -1
+	bestVerifierScore := 0
+	ranking := ranking{enabled: s.solver.explain}
+	for i := range s.game.GetVerifierCards() {
+		if s.askedThisRound(i) {
+			continue
+		}
+
+		score := s.solver.verifierStrategy(s, i, code)
+		if score > 0 {
+			ranking.add(Alternative{Code: code, Verifier: i, Score: score})
+		}
+		if score > bestVerifierScore {
+			bestVerifierScore = score
+			bestVerifierIndex = i
+		}
+	}
+
+	return bestVerifierIndex, bestVerifierScore, ranking.runnersUp()
+}
+
+// setCandidates updates the solutions that are still possible
+func (s *Session) setCandidates(candidates solutionSet) {
+	s.candidates = candidates
+	s.solutions = s.space.toSolutions(candidates)
+}
+
+// adjustSolutions returns the candidates that would give the answer valid when code is tested against the verifier
+func (s *Session) adjustSolutions(code []int, verifierIndex int, valid bool) solutionSet {
+	return s.filterSolutions(s.candidates, code, verifierIndex, valid)
+}
+
+// filterSolutions returns the solutions that would give the answer valid when code is tested against the verifier
+func (s *Session) filterSolutions(solutions solutionSet, code []int, verifierIndex int, valid bool) solutionSet {
+	return s.space.split(solutions, code, verifierIndex, valid)
+}
+
+// countCodes returns the number of distinct codes in solutions
+func (s *Session) countCodes(solutions solutionSet) int {
+	return s.space.countCodes(solutions)
+}
@@ -1,15 +1,16 @@
 package solver
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"sync"
+	"slices"
 
 	"github.com/caseymerrill/turingsolver/game"
-	"github.com/caseymerrill/turingsolver/set"
 	"github.com/caseymerrill/turingsolver/verifiers"
 )
 
+// Solver is an immutable definition of how to play, it starts a Session for every game it plays.
+// One Solver can play any number of games at the same time.
 type Solver struct {
 	name string
 
@@ -19,29 +20,41 @@ type Solver struct {
 	// verifierStrategy used for picking verifiers to query
 	verifierStrategy VerifierStrategy
 
-	// progressCallback will be called with a string describing the progress so far, may be left nil
-	progressCallback ProgressCallback
+	// combinedStrategy picks a code together with the verifiers to query, replaces codeStrategy and verifierStrategy
+	combinedStrategy CombinedStrategy
 
-	// verifiersTestedThisCode is the number of verifiers tested for the current code
-	verifiersTestedThisCode int
+	// observers are told about every event of the sessions
+	observers []Observer
 
-	game      game.Game
-	solutions []game.Solution
+	// budget limits the codes and questions used before giving up, DefaultBudget is used when nil
+	budget *Budget
+
+	// noise is how answers that contradict every solution are handled
+	noise NoiseMode
+
+	// explain adds explanations to the events of every choice
+	explain bool
 }
 
-type ProgressCallback func(string)
-type CodeStrategy func(*Solver, []int) int
-type VerifierStrategy func(*Solver, int, []int) int
-type CombinedStrategy func(*Solver, []int) (int, []int)
+// questionsPerCode is the most verifiers the strategies plan to test against each code, games may allow fewer
+const questionsPerCode = 3
+
+type CodeStrategy func(*Session, []int) int
+type VerifierStrategy func(*Session, int, []int) int
+type CombinedStrategy func(*Session, []int) (int, []int)
+
+func init() {
+	RegisterSolver("notimplemented", "Panics, for testing games", nil, noParams(NotImplementedSolver))
+}
 
-// NotImplementedSolver can't solve any games, but can be used to get the inital solutions for game generation
+// NotImplementedSolver can't solve any games
 func NotImplementedSolver() *Solver {
 	return &Solver{
 		name: "Not Implemented",
-		codeStrategy: func(*Solver, []int) int {
+		codeStrategy: func(*Session, []int) int {
 			panic("Not implemented")
 		},
-		verifierStrategy: func(*Solver, int, []int) int {
+		verifierStrategy: func(*Session, int, []int) int {
 			panic("Not implemented")
 		},
 	}
@@ -51,206 +64,79 @@ func (s *Solver) GetPlayerName() string {
 	return s.name
 }
 
-func (s *Solver) SetProgressCallback(callback ProgressCallback) *Solver {
-	s.progressCallback = callback
-	return s
+// WithObserver returns a copy of the solver that also tells observer about the events of its sessions
+func (s *Solver) WithObserver(observer Observer) *Solver {
+	withObserver := *s
+	withObserver.observers = append(slices.Clip(s.observers), observer)
+	return &withObserver
 }
 
-// Reset clears game, and solution state, but keep configuration like progress callback
-func (s *Solver) reset() {
-	s.game = nil
-	s.solutions = nil
+// WithBudget returns a copy of the solver that gives up once it has used the budget
+func (s *Solver) WithBudget(budget Budget) *Solver {
+	withBudget := *s
+	withBudget.budget = &budget
+	return &withBudget
 }
 
-func (s *Solver) Solve(gameToSolve game.Game) (bool, game.Solution) {
-	s.solutions = s.InitialSolutions(gameToSolve)
-	s.progressReport()
-
-	var codesTested [][]int
-	for len(s.solutions) > 0 && !s.hasSolution() {
-		code := s.selectCode()
-
-		for i := 0; i < 3; i++ {
-			var verifier int
-			verifier = s.selectVerifier(code)
-
-			if verifier == -1 {
-				if s.progressCallback != nil {
-					s.progressCallback("No useful verifiers for code")
-				}
-				break
-			}
-
-			valid := s.game.AskQuestion(s, code, verifier)
-			s.verifiersTestedThisCode += 1
-			s.solutions = s.adjustSolutions(code, verifier, valid)
-			if s.hasSolution() {
-				break
-			}
-
-			s.progressReport()
-		}
-
-		codesTested = append(codesTested, code)
-		if len(codesTested) > 100 {
-			fmt.Println(s.GetPlayerName(), " has tested 100 codes, giving up")
-			fmt.Printf("Codes tested:\n%+v\n", codesTested)
-			fmt.Println("Solutions:")
-			for _, solution := range s.solutions {
-				fmt.Printf("\t%+v\n", solution)
-			}
-			return false, game.Solution{}
-		}
-	}
-
-	if len(s.solutions) == 0 {
-		log.Fatal("No solutions found")
-	}
-
-	return s.game.MakeGuess(s, s.solutions[0].Code), s.solutions[0]
+// WithNoise returns a copy of the solver that handles wrong answers with mode
+func (s *Solver) WithNoise(mode NoiseMode) *Solver {
+	withNoise := *s
+	withNoise.noise = mode
+	return &withNoise
 }
 
-func (s *Solver) InitialSolutions(gameToSolve game.Game) []game.Solution {
-	s.reset()
-	s.game = gameToSolve
-	solutions := make(chan game.Solution, 100)
-	wg := sync.WaitGroup{}
-	for vp := range s.getAllVerifierPermutations() {
-		wg.Add(1)
-		go func(verifierPermutation []*verifiers.Verifier) {
-			defer wg.Done()
-			var validCode []int
-			for _, code := range possibleCodes {
-				if verifyCode(code, verifierPermutation) {
-					if validCode != nil {
-						// One solution per valid verifier permutation
-						validCode = nil
-						break
-					}
-
-					validCode = code
-				}
-			}
-
-			if validCode != nil && allValidatorsUseful(verifierPermutation) {
-				solutions <- game.Solution{
-					Code:      validCode,
-					Verifiers: verifierPermutation,
-				}
-			}
-		}(vp)
-	}
-
-	go func() {
-		wg.Wait()
-		close(solutions)
-	}()
-
-	var result []game.Solution
-	for solution := range solutions {
-		result = append(result, solution)
-	}
-
-	return result
-}
-
-func (s *Solver) progressReport() {
-	if s.progressCallback == nil {
-		return
-	}
-
-	progressReport := fmt.Sprintf("Found %v solutions:\n", len(s.solutions))
-	for _, solution := range s.solutions {
-		progressReport += fmt.Sprintf("  %v\n", solution)
+func (s *Solver) getBudget() Budget {
+	if s.budget == nil {
+		return DefaultBudget
 	}
 
-	s.progressCallback(progressReport)
+	return *s.budget
 }
 
-// hasSolution returns true if all possible solutions use the same code
-func (s *Solver) hasSolution() bool {
-	if len(s.solutions) == 0 {
-		return false
-	}
-
-	code := s.solutions[0].Code
-	for _, solution := range s.solutions[1:] {
-		for i, c := range code {
-			if c != solution.Code[i] {
-				return false
-			}
-		}
-	}
-
-	return true
-}
-
-func (s *Solver) selectCode() []int {
-	bestScore := -1
-	var bestCode []int
-	for _, code := range possibleCodes {
-		var score int
-		score = s.codeStrategy(s, code)
-
-		if score > bestScore {
-			bestScore = score
-			bestCode = code
-		}
+// Solve plays the game until it finds the code, printing the reason when it fails
+func (s *Solver) Solve(gameToSolve game.Game) (bool, game.Solution) {
+	result, err := s.SolveContext(context.Background(), gameToSolve)
+	if err != nil {
+		fmt.Println(s.GetPlayerName(), "failed to solve:", err)
+		return false, game.Solution{}
 	}
 
-	return bestCode
+	return result.Correct, game.Solution{Code: result.Code, Verifiers: result.Verifiers}
 }
 
-func (s *Solver) selectVerifier(code []int) int {
-	bestVerifierIndex := -1
-	bestVerifierScore := 0
-	for i := range s.game.GetVerifierCards() {
-		score := s.verifierStrategy(s, i, code)
-		if score > bestVerifierScore {
-			bestVerifierScore = score
-			bestVerifierIndex = i
-		}
-	}
-
-	return bestVerifierIndex
+// SolveContext plays the game in a new session until it finds the code, see Session.Solve
+func (s *Solver) SolveContext(ctx context.Context, gameToSolve game.Game) (Result, error) {
+	return s.NewSession(gameToSolve).Solve(ctx)
 }
 
-func (s *Solver) adjustSolutions(code []int, verifierIndex int, valid bool) []game.Solution {
-	verifiersToKeep := set.Make[*verifiers.Verifier]()
-	for _, verifier := range s.game.GetVerifierCards()[verifierIndex].Verifiers {
-		if verifier.Verify(code...) == valid {
-			verifiersToKeep.Add(verifier)
-		}
-	}
-
-	newSolutions := make([]game.Solution, 0, len(s.solutions))
-	for _, solution := range s.solutions {
-		if verifiersToKeep.Contains(solution.Verifiers[verifierIndex]) {
-			newSolutions = append(newSolutions, solution)
+// InitialSolutions finds every solution of the game, each combination of one verifier per card that accepts exactly
+// one code and where every verifier is needed.
+func InitialSolutions(gameToSolve game.Game) []game.Solution {
+	var solutions []game.Solution
+	verifierPermutationHelper(gameToSolve.GetVerifierCards(), []*verifiers.Verifier{}, allCodes, func(verifierPermutation []*verifiers.Verifier, validCodes codeMask) {
+		// One solution per valid verifier permutation
+		if validCodes.count() == 1 && allValidatorsUseful(verifierPermutation) {
+			solutions = append(solutions, game.Solution{
+				Code:      possibleCodes[validCodes.first()],
+				Verifiers: verifierPermutation,
+			})
 		}
-	}
+	})
 
-	return newSolutions
+	return solutions
 }
 
 func allValidatorsUseful(verifierPermutation []*verifiers.Verifier) bool {
 	for i := range verifierPermutation {
-		holdout := make([]*verifiers.Verifier, len(verifierPermutation)-1)
-		copy(holdout, verifierPermutation[:i])
-		copy(holdout[i:], verifierPermutation[i+1:])
-		validCodes := 0
-		for _, code := range possibleCodes {
-			if verifyCode(code, holdout) {
-				validCodes += 1
-			}
-
-			if validCodes > 1 {
-				// The withheld verifier is useful
-				break
+		validCodes := allCodes
+		for j, verifier := range verifierPermutation {
+			if i != j {
+				validCodes = validCodes.and(truthTable(verifier))
 			}
 		}
 
-		if validCodes <= 1 {
+		if validCodes.count() <= 1 {
+			// The withheld verifier is not needed
 			return false
 		}
 	}
@@ -258,39 +144,22 @@ func allValidatorsUseful(verifierPermutation []*verifiers.Verifier) bool {
 	return true
 }
 
-func verifyCode(code []int, verifierPermutation []*verifiers.Verifier) bool {
-	for _, verifier := range verifierPermutation {
-		if !verifier.Verify(code...) {
-			return false
-		}
+// verifierPermutationHelper calls found with every combination of one verifier per card that accepts at least one
+// code, along with the codes it accepts.
+func verifierPermutationHelper(cards []*verifiers.VerifierCard, verifiersSoFar []*verifiers.Verifier, validCodes codeMask, found func([]*verifiers.Verifier, codeMask)) {
+	if len(verifiersSoFar) == len(cards) {
+		verifierPermutation := make([]*verifiers.Verifier, len(verifiersSoFar))
+		copy(verifierPermutation, verifiersSoFar)
+		found(verifierPermutation, validCodes)
+		return
 	}
 
-	return true
-}
-
-func (s *Solver) getAllVerifierPermutations() chan []*verifiers.Verifier {
-	result := make(chan []*verifiers.Verifier, 100)
-
-	go func() {
-		defer close(result)
-		s.verifierPermutationHelper([]int{}, result)
-	}()
-
-	return result
-}
-
-func (s *Solver) verifierPermutationHelper(indexesSoFar []int, result chan []*verifiers.Verifier) {
-	if len(indexesSoFar) == len(s.game.GetVerifierCards()) {
-		verifierPermutation := make([]*verifiers.Verifier, len(indexesSoFar))
-		for i, index := range indexesSoFar {
-			verifierPermutation[i] = s.game.GetVerifierCards()[i].Verifiers[index]
+	for _, verifier := range cards[len(verifiersSoFar)].Verifiers {
+		nextValidCodes := validCodes.and(truthTable(verifier))
+		if nextValidCodes.count() == 0 {
+			continue
 		}
 
-		result <- verifierPermutation
-		return
-	}
-
-	for i := 0; i < len(s.game.GetVerifierCards()[len(indexesSoFar)].Verifiers); i++ {
-		s.verifierPermutationHelper(append(indexesSoFar, i), result)
+		verifierPermutationHelper(cards, append(verifiersSoFar, verifier), nextValidCodes, found)
 	}
 }
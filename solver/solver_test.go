@@ -0,0 +1,358 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/verifiers"
+)
+
+func testCards(cardNumbers ...int) []*verifiers.VerifierCard {
+	cards := make([]*verifiers.VerifierCard, len(cardNumbers))
+	for i, cardNumber := range cardNumbers {
+		cards[i] = &verifiers.Cards[cardNumber-1]
+	}
+
+	return cards
+}
+
+func TestTruthTableMatchesVerify(t *testing.T) {
+	for _, card := range verifiers.Cards {
+		for _, verifier := range card.Verifiers {
+			mask := truthTable(verifier)
+			for i, code := range possibleCodes {
+				if mask.has(i) != verifier.Verify(code...) {
+					t.Fatalf("card %v verifier %v code %v expected: %v", card.CardNumber, verifier, code, verifier.Verify(code...))
+				}
+			}
+		}
+	}
+}
+
+func TestInitialSolutionsHaveUniqueCodes(t *testing.T) {
+	solutions := InitialSolutions(game.NewInteractiveGame(testCards(4, 9, 11, 14)))
+	if len(solutions) == 0 {
+		t.Fatal("expected solutions")
+	}
+
+	for _, solution := range solutions {
+		validCodes := 0
+		for _, code := range possibleCodes {
+			accepted := true
+			for _, verifier := range solution.Verifiers {
+				accepted = accepted && verifier.Verify(code...)
+			}
+
+			if accepted {
+				validCodes += 1
+				if !slices.Equal(code, solution.Code) {
+					t.Fatalf("%v accepts %v", solution, code)
+				}
+			}
+		}
+
+		if validCodes != 1 {
+			t.Fatalf("%v accepts %v codes", solution, validCodes)
+		}
+	}
+}
+
+func TestAdjustSolutionsMatchesVerify(t *testing.T) {
+	s := NotImplementedSolver().NewSession(game.NewInteractiveGame(testCards(2, 7, 10, 15, 17, 23)))
+	for verifierIndex := range s.game.GetVerifierCards() {
+		for _, code := range possibleCodes {
+			for _, valid := range []bool{true, false} {
+				adjusted := s.adjustSolutions(code, verifierIndex, valid)
+				for solutionIndex, solution := range s.solutions {
+					expected := solution.Verifiers[verifierIndex].Verify(code...) == valid
+					if adjusted.contains(solutionIndex) != expected {
+						t.Fatalf("%v verifier %v code %v valid %v expected: %v", solution, verifierIndex, code, valid, expected)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestOptimalIsNeverBeaten(t *testing.T) {
+	cards := testCards(18, 30, 25, 46, 47)
+	solutions := InitialSolutions(game.NewInteractiveGame(cards))
+
+	for _, rules := range []game.Rules{game.DefaultRules, {QuestionsPerRound: 1}} {
+		var optimalWorstCase, solverWorstCase Cost
+		for _, secret := range solutions {
+			optimalPlay, err := Optimal(cards, rules, &secret)
+			if err != nil {
+				t.Fatal(err)
+			} else if optimalPlay.WorstCase.Less(optimalPlay.Secret) {
+				t.Fatalf("%+v %v optimal for secret %v is worse than worst case %v", rules, secret, optimalPlay.Secret, optimalPlay.WorstCase)
+			}
+			optimalWorstCase = optimalPlay.WorstCase
+
+			autoGame := game.NewAutoGame(cards, secret.Verifiers, secret.Code)
+			autoGame.SetRules(rules)
+			session := Combinator1_1().NewSession(autoGame)
+			if result, err := session.Solve(context.Background()); err != nil || !result.Correct {
+				t.Fatalf("%+v %v not solved: %v", rules, secret, err)
+			}
+
+			moves := autoGame.Stats()[session]
+			cost := Cost{Codes: moves.CodesTested(), Questions: moves.QuestionsAsked()}
+			if cost.Less(optimalPlay.Secret) {
+				t.Fatalf("%+v %v solved with %v, optimal for secret is %v", rules, secret, cost, optimalPlay.Secret)
+			}
+
+			if solverWorstCase.Less(cost) {
+				solverWorstCase = cost
+			}
+		}
+
+		if solverWorstCase.Less(optimalWorstCase) {
+			t.Fatalf("%+v solver worst case %v beats optimal worst case %v", rules, solverWorstCase, optimalWorstCase)
+		}
+	}
+}
+
+func testGame(cardNumbers ...int) *game.AutoGame {
+	cards := testCards(cardNumbers...)
+	secret := InitialSolutions(game.NewInteractiveGame(cards))[0]
+	return game.NewAutoGame(cards, secret.Verifiers, secret.Code)
+}
+
+func TestSolveContextErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Combinator1_1().SolveContext(canceled, testGame(18, 30, 25, 46, 47)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled: %v", err)
+	}
+
+	result, err := Combinator1_1().WithBudget(Budget{Questions: 1}).SolveContext(context.Background(), testGame(18, 30, 25, 46, 47))
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("expected budget exhausted: %v", err)
+	} else if result.QuestionsAsked != 1 || result.CodesTested != 1 {
+		t.Fatalf("expected one code and one question: %+v", result)
+	}
+
+	if _, err := Combinator1_1().SolveContext(context.Background(), game.NewInteractiveGame(testCards(1, 1))); !errors.Is(err, ErrNoSolutions) {
+		t.Fatalf("expected no solutions: %v", err)
+	}
+
+	// The game turns down a second guess instead of answering it
+	session := Combinator1_1().NewSession(testGame(18, 30, 25, 46, 47))
+	if _, err := session.Solve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var gameErr *GameError
+	if _, err := session.Solve(context.Background()); !errors.As(err, &gameErr) || !errors.Is(err, game.ErrAlreadyGuessed) {
+		t.Fatalf("expected already guessed: %v", err)
+	}
+}
+
+func TestSolverPlaysGamesConcurrently(t *testing.T) {
+	cards := testCards(18, 30, 25, 46, 47)
+	s := Combinator1_1()
+	errs := make(chan error)
+	solutions := InitialSolutions(game.NewInteractiveGame(cards))
+	for _, secret := range solutions {
+		go func(secret game.Solution) {
+			result, err := s.SolveContext(context.Background(), game.NewAutoGame(cards, secret.Verifiers, secret.Code))
+			if err == nil && !slices.Equal(result.Code, secret.Code) {
+				err = fmt.Errorf("guessed %v expected %v", result.Code, secret.Code)
+			}
+			errs <- err
+		}(secret)
+	}
+
+	for range solutions {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"best", "PC1.1", "pc1.1(maxCodes=20)", "code=topthree(n=2),verifier=pessimistic,name=MyBot", "combined=combinator2", "code=lookahead(mode=expected),verifier=lookahead"} {
+		s, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("%v : %v", spec, err)
+		}
+
+		if result, err := s.SolveContext(context.Background(), testGame(18, 30, 25, 46, 47)); err != nil || !result.Correct {
+			t.Fatalf("%v didn't solve the game: %+v %v", spec, result, err)
+		}
+	}
+
+	if s, _ := Parse("pc1.1(maxCodes=20,maxQuestions=7)"); s.getBudget() != (Budget{Codes: 20, Questions: 7}) {
+		t.Fatalf("budget not applied: %+v", s.getBudget())
+	} else if s, _ := Parse("code=topthree,verifier=pessimistic,name=MyBot"); s.GetPlayerName() != "MyBot" {
+		t.Fatalf("name not applied: %v", s.GetPlayerName())
+	}
+
+	for _, spec := range []string{"unknown", "pc1.1(n=2)", "code=topthree", "code=topthree,verifier=pessimistic,combined=combinator2", "code=topthree(n=x),verifier=pessimistic", "pc1.1(maxCodes=20"} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("expected an error parsing %v", spec)
+		}
+	}
+}
+
+func TestObserverSeesEveryMove(t *testing.T) {
+	var events []Event
+	observed := Combinator1_1().WithObserver(ObserverFunc(func(s *Session, event Event) {
+		events = append(events, event)
+	}))
+
+	result, err := observed.SolveContext(context.Background(), testGame(18, 30, 25, 46, 47))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[event.EventName()] += 1
+	}
+
+	if _, ok := events[0].(SolveStarted); !ok {
+		t.Fatalf("first event is %v", events[0].EventName())
+	} else if finished, ok := events[len(events)-1].(SolveFinished); !ok || finished.Result.Correct != result.Correct {
+		t.Fatalf("last event is %v", events[len(events)-1].EventName())
+	} else if counts["codeSelected"] != result.CodesTested || counts["answerReceived"] != result.QuestionsAsked || counts["guessMade"] != 1 {
+		t.Fatalf("events %v don't match result %+v", counts, result)
+	}
+}
+
+// lyingGame gives the wrong answer to one question
+type lyingGame struct {
+	*game.AutoGame
+	lieAt   int
+	answers int
+}
+
+func (g *lyingGame) AskQuestion(player game.Player, code []int, verifier int) (bool, error) {
+	valid, err := g.AutoGame.AskQuestion(player, code, verifier)
+	g.answers += 1
+	return valid != (g.answers == g.lieAt+1), err
+}
+
+func TestNoiseRecoversFromOneWrongAnswer(t *testing.T) {
+	for _, mode := range []NoiseMode{NoiseReask, NoisePlausible} {
+		for lieAt := 0; lieAt < 6; lieAt++ {
+			lying := &lyingGame{AutoGame: testGame(18, 30, 25, 46, 47), lieAt: lieAt}
+			result, err := Combinator1_1().WithNoise(mode).SolveContext(context.Background(), lying)
+			if err != nil || !result.Correct {
+				t.Fatalf("%v with answer %v wrong: %+v %v", mode, lieAt+1, result, err)
+			}
+		}
+	}
+
+	if s, err := Parse("best(noise=reask)"); err != nil || s.noise != NoiseReask {
+		t.Fatalf("noise not applied: %v", err)
+	}
+}
+
+func TestExplanationsDontChangeChoices(t *testing.T) {
+	plain, err := Combinator1_1().SolveContext(context.Background(), testGame(18, 30, 25, 46, 47))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explained, err := Combinator1_1().WithExplanations().WithObserver(ObserverFunc(func(s *Session, event Event) {
+		selected, ok := event.(CodeSelected)
+		if !ok {
+			return
+		} else if selected.Explanation == nil || len(selected.Explanation.Splits) == 0 {
+			t.Fatalf("code %v not explained", selected.Code)
+		}
+
+		for _, runnerUp := range selected.Explanation.RunnersUp {
+			if runnerUp.Score > selected.Score || slices.Equal(runnerUp.Code, selected.Code) {
+				t.Fatalf("runner up %+v beats code %v with score %v", runnerUp, selected.Code, selected.Score)
+			}
+		}
+	})).SolveContext(context.Background(), testGame(18, 30, 25, 46, 47))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(plain, explained) {
+		t.Fatalf("explaining changed the result: %+v %+v", plain, explained)
+	}
+}
+
+func TestRecommendFollowsSolve(t *testing.T) {
+	for _, newSolver := range []func() *Solver{Combinator1_1, Combinator2, Entropy} {
+		played := newSolver().NewSession(testGame(18, 30, 25, 46, 47))
+		result, err := played.Solve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		replayed := newSolver().NewSession(game.NewInteractiveGame(testCards(18, 30, 25, 46, 47)))
+		for _, answer := range played.Answers() {
+			hint, err := replayed.Recommend()
+			if err != nil {
+				t.Fatal(err)
+			} else if hint.Solved || !slices.Equal(hint.Code, answer.Code) || hint.Verifier != answer.Verifier {
+				t.Fatalf("%v recommended %+v but asked %v", played.GetPlayerName(), hint, answer)
+			} else if err := replayed.Apply(answer); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if hint, err := replayed.Recommend(); err != nil || !hint.Solved || !slices.Equal(hint.Code, result.Code) {
+			t.Fatalf("%v recommended %+v but guessed %v: %v", played.GetPlayerName(), hint, result.Code, err)
+		}
+	}
+}
+
+func TestSolveResumesAppliedAnswers(t *testing.T) {
+	for _, newSolver := range []func() *Solver{Combinator1_1, Combinator2, Entropy} {
+		played := newSolver().NewSession(testGame(18, 30, 25, 46, 47))
+		if _, err := played.Solve(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		// Stop partway through a round so the resumed session has to finish it
+		answers := played.Answers()
+		resumed := newSolver().NewSession(testGame(18, 30, 25, 46, 47))
+		for _, answer := range answers[:len(answers)/2+1] {
+			if err := resumed.Apply(answer); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := resumed.Solve(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(resumed.Answers(), answers) {
+			t.Fatalf("%v asked %v after resuming but %v without stopping", played.GetPlayerName(), resumed.Answers(), answers)
+		}
+	}
+}
+
+func TestSolveFollowsGameRules(t *testing.T) {
+	oneQuestion := testGame(18, 30, 25, 46, 47)
+	oneQuestion.SetRules(game.Rules{QuestionsPerRound: 1})
+	result, err := Combinator1_1().SolveContext(context.Background(), oneQuestion)
+	if err != nil || !result.Correct {
+		t.Fatalf("expected a correct guess: %+v %v", result, err)
+	} else if result.CodesTested != result.QuestionsAsked {
+		t.Fatalf("expected one question per code: %+v", result)
+	}
+
+	for player, moves := range oneQuestion.Stats() {
+		if moves.CodesTested() != result.CodesTested || moves.QuestionsAsked() != result.QuestionsAsked {
+			t.Fatalf("game counted %v codes and %v questions for %v: %+v", moves.CodesTested(), moves.QuestionsAsked(), player.GetPlayerName(), result)
+		}
+	}
+}
+
+func TestSolveWithoutQuestionLimit(t *testing.T) {
+	unlimited := testGame(18, 30, 25, 46, 47)
+	unlimited.SetRules(game.Rules{QuestionsPerRound: 0})
+	result, err := Combinator1_1().SolveContext(context.Background(), unlimited)
+	if err != nil || !result.Correct {
+		t.Fatalf("expected a correct guess without a question limit: %+v %v", result, err)
+	}
+}
@@ -1,23 +1,48 @@
 package solver
 
-import "slices"
+import (
+	"errors"
+	"slices"
+)
 
-// sumBiggestThree returns the sum of the three largest numbers in the slice, will sort the slice int the process
-func sumBiggestThree(scores []int) int {
+func init() {
+	RegisterCodeStrategy("topthree", "Scores a code by the sum of its n best verifier scores", []Param{
+		{Name: "n", Description: "Number of verifiers to sum", Default: "3"},
+	}, func(params Params) (CodeStrategy, error) {
+		n, err := params.Int("n", 3)
+		if err != nil {
+			return nil, err
+		} else if n < 1 {
+			return nil, errors.New("n must be at least 1")
+		}
+
+		return topVerifiersCodeStrategy(n), nil
+	})
+}
+
+// sumBiggest returns the sum of the n largest numbers in the slice, will sort the slice int the process
+func sumBiggest(scores []int, n int) int {
 	slices.Sort(scores)
 	finalScore := 0
-	for _, score := range scores[len(scores)-3:] {
+	for _, score := range scores[max(0, len(scores)-n):] {
 		finalScore += score
 	}
 	return finalScore
 }
 
 // topThreeVerifiersCodeStrategy returns code whose top three verifiers have the highest score
-func topThreeVerifiersCodeStategy(s *Solver, code []int) int {
-	scores := make([]int, len(s.game.GetVerifierCards()))
-	for i := range s.game.GetVerifierCards() {
-		scores[i] = s.verifierStrategy(s, i, code)
-	}
+func topThreeVerifiersCodeStategy(s *Session, code []int) int {
+	return topVerifiersCodeStrategy(3)(s, code)
+}
+
+// topVerifiersCodeStrategy scores a code by the sum of its n highest verifier scores
+func topVerifiersCodeStrategy(n int) CodeStrategy {
+	return func(s *Session, code []int) int {
+		scores := make([]int, len(s.game.GetVerifierCards()))
+		for i := range s.game.GetVerifierCards() {
+			scores[i] = s.solver.verifierStrategy(s, i, code)
+		}
 
-	return sumBiggestThree(scores)
+		return sumBiggest(scores, n)
+	}
 }
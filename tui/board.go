@@ -0,0 +1,335 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/caseymerrill/turingsolver/game"
+	"github.com/caseymerrill/turingsolver/verifiers"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Board is a full screen interactive game: the verifier cards with the options that have been ruled out greyed, the
+// answers of every round and the solutions still possible. Questions are answered with key presses, the same commands
+// as game.InteractiveGame stop the game with game.ErrQuit or a *game.AnswersEdited.
+type Board struct {
+	screen tcell.Screen
+	cards  []*verifiers.VerifierCard
+
+	// status is a message shown above the question, such as an error saving the game
+	status string
+}
+
+// history is implemented by players that can show what they know on the board
+type history interface {
+	Solutions() []game.Solution
+	Answers() []game.Answer
+}
+
+const keyHelp = "y yes   n no   u undo   c change an answer   q quit"
+const changeHelp = "←/→ choose an answer   enter flip it   esc cancel"
+
+var (
+	plainStyle      = tcell.StyleDefault
+	titleStyle      = plainStyle.Bold(true)
+	eliminatedStyle = plainStyle.Foreground(tcell.ColorGray)
+	foundStyle      = plainStyle.Foreground(tcell.ColorGreen).Bold(true)
+	askingStyle     = plainStyle.Reverse(true)
+)
+
+// NewBoard takes over the terminal until Close
+func NewBoard(cards []*verifiers.VerifierCard) (*Board, error) {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, fmt.Errorf("opening terminal : %w", err)
+	} else if err := screen.Init(); err != nil {
+		return nil, fmt.Errorf("opening terminal : %w", err)
+	}
+
+	return &Board{screen: screen, cards: cards}, nil
+}
+
+// SetStatus shows a message above the question the next time the board is drawn
+func (b *Board) SetStatus(status string) {
+	b.status = status
+}
+
+// Close gives the terminal back
+func (b *Board) Close() {
+	b.screen.Fini()
+}
+
+func (b *Board) GetVerifierCards() []*verifiers.VerifierCard {
+	return b.cards
+}
+
+// Rules are the board game's, whoever runs the game at the table enforces them
+func (b *Board) Rules() game.Rules {
+	return game.DefaultRules
+}
+
+func (b *Board) StartRound(player game.Player, code []int) error {
+	return game.CheckCode(code)
+}
+
+func (b *Board) EndRound(player game.Player) error {
+	return nil
+}
+
+func (b *Board) AskQuestion(player game.Player, code []int, verifierIndex int) (bool, error) {
+	if err := game.CheckMove(code, verifierIndex, len(b.cards)); err != nil {
+		return false, err
+	}
+
+	return b.prompt(player, fmt.Sprintf("Test code %v against verifier %v?", game.CodeString(code), verifierIndex+1), verifierIndex)
+}
+
+func (b *Board) MakeGuess(player game.Player, code []int) (bool, error) {
+	return b.prompt(player, fmt.Sprintf("Guess code %v, was it right?", game.CodeString(code)), -1)
+}
+
+func (b *Board) String() string {
+	return game.NewInteractiveGame(b.cards).String()
+}
+
+// Rank is empty, nobody else plays at the board
+func (b *Board) Rank() [][]game.Player {
+	return nil
+}
+
+// prompt waits for a key press answering question, or for one that stops the game
+func (b *Board) prompt(player game.Player, question string, verifierIndex int) (bool, error) {
+	var answers []game.Answer
+	if known, ok := player.(history); ok {
+		answers = slices.Clone(known.Answers())
+	}
+
+	// selected is the answer chosen to change, -1 while answering
+	selected := -1
+	for {
+		b.draw(player, question, verifierIndex, selected)
+
+		switch event := b.screen.PollEvent().(type) {
+		case *tcell.EventResize:
+			b.screen.Sync()
+		case *tcell.EventKey:
+			if event.Key() == tcell.KeyCtrlC {
+				return false, game.ErrQuit
+			} else if selected != -1 {
+				switch {
+				case event.Key() == tcell.KeyLeft || event.Key() == tcell.KeyUp:
+					selected = max(0, selected-1)
+				case event.Key() == tcell.KeyRight || event.Key() == tcell.KeyDown:
+					selected = min(len(answers)-1, selected+1)
+				case event.Key() == tcell.KeyEnter:
+					answers[selected].Valid = !answers[selected].Valid
+					return false, &game.AnswersEdited{Answers: answers}
+				case event.Key() == tcell.KeyEscape:
+					selected = -1
+				}
+				continue
+			}
+
+			switch event.Rune() {
+			case 'y', 'Y':
+				return true, nil
+			case 'n', 'N':
+				return false, nil
+			case 'q', 'Q':
+				return false, game.ErrQuit
+			case 'u', 'U':
+				if len(answers) > 0 {
+					return false, &game.AnswersEdited{Answers: answers[:len(answers)-1]}
+				}
+			case 'c', 'C':
+				selected = len(answers) - 1
+			}
+		}
+	}
+}
+
+// draw shows the game as the player knows it. verifierIndex is the verifier being asked about, -1 when guessing.
+// selected is the answer chosen to change, -1 when none is.
+func (b *Board) draw(player game.Player, question string, verifierIndex int, selected int) {
+	var solutions []game.Solution
+	var answers []game.Answer
+	if known, ok := player.(history); ok {
+		solutions, answers = known.Solutions(), known.Answers()
+	}
+
+	b.screen.Clear()
+	width, height := b.screen.Size()
+	y := 0
+	y = b.text(0, y, width, fmt.Sprintf("Turing Machine: %v solutions left", len(solutions)), titleStyle) + 1
+
+	for i, card := range b.cards {
+		style := titleStyle
+		if i == verifierIndex {
+			style = askingStyle
+		}
+		label := fmt.Sprintf("%v (card %v):", i+1, card.CardNumber)
+		x := b.write(0, y, width, label, style)
+
+		for _, option := range card.Verifiers {
+			possible := false
+			for _, solution := range solutions {
+				possible = possible || solution.Verifiers[i] == option
+			}
+
+			optionStyle := plainStyle
+			if !possible {
+				optionStyle = eliminatedStyle
+			} else if solved(solutions, i) {
+				optionStyle = foundStyle
+			}
+
+			if x+2+visibleLength(option.Description) > width {
+				x, y = len(label), y+1
+			}
+			x = b.write(x+2, y, width, option.Description, optionStyle)
+		}
+		y += 1
+	}
+	y += 1
+
+	// Rounds are drawn the way the punch cards are, one row per code with a column for every verifier
+	footer := height - 3
+	header := "Round  Code "
+	for i := range b.cards {
+		header += fmt.Sprintf(" %-2v", i+1)
+	}
+	y = b.text(0, y, width, header, titleStyle)
+	played := rounds(answers, b.Rules().QuestionsPerRound)
+	for round := max(0, len(played)-(footer-y)/2); round < len(played); round++ {
+		x := b.write(0, y, width, fmt.Sprintf("%-6v %v ", round+1, game.CodeString(answers[played[round][0]].Code)), plainStyle)
+		for _, answerIndex := range played[round] {
+			mark, style := "✗", plainStyle.Foreground(tcell.ColorRed)
+			if answers[answerIndex].Valid {
+				mark, style = "✓", plainStyle.Foreground(tcell.ColorGreen)
+			}
+			if answerIndex == selected {
+				style = style.Reverse(true)
+			}
+			b.write(x+2+3*answers[answerIndex].Verifier, y, width, mark, style)
+		}
+		y += 1
+	}
+	y += 1
+
+	for i, solution := range solutions {
+		if y >= footer {
+			break
+		} else if y == footer-1 && i < len(solutions)-1 {
+			b.text(0, y, width, fmt.Sprintf("... and %v more", len(solutions)-i), eliminatedStyle)
+			break
+		}
+		y = b.text(0, y, width, solution.String(), plainStyle)
+	}
+
+	if b.status != "" {
+		b.text(0, height-3, width, b.status, plainStyle.Foreground(tcell.ColorRed))
+	}
+	if selected != -1 {
+		b.text(0, height-2, width, fmt.Sprintf("Change answer %v: %v", selected+1, answers[selected]), titleStyle)
+		b.text(0, height-1, width, changeHelp, eliminatedStyle)
+	} else {
+		b.text(0, height-2, width, question, titleStyle)
+		b.text(0, height-1, width, keyHelp, eliminatedStyle)
+	}
+
+	b.screen.Show()
+}
+
+// text writes a line and returns the next line
+func (b *Board) text(x, y, width int, text string, style tcell.Style) int {
+	b.write(x, y, width, text, style)
+	return y + 1
+}
+
+// write draws text with the colors of its ANSI escapes, as used by verifier descriptions, and returns the column after
+// it. Text past width is cut off.
+func (b *Board) write(x, y, width int, text string, style tcell.Style) int {
+	current := style
+	for len(text) > 0 {
+		if strings.HasPrefix(text, "\033[") {
+			end := strings.IndexByte(text, 'm')
+			if end == -1 {
+				break
+			}
+			current = escapeStyle(text[2:end], style)
+			text = text[end+1:]
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		if x < width {
+			b.screen.SetContent(x, y, r, nil, current)
+		}
+		x += 1
+	}
+
+	return x
+}
+
+// escapeStyle returns the style of an ANSI color code, greyed text stays grey
+func escapeStyle(code string, style tcell.Style) tcell.Style {
+	if fg, _, _ := style.Decompose(); fg == tcell.ColorGray {
+		return style
+	}
+
+	n, err := strconv.Atoi(code)
+	if err != nil || n < 30 || n > 37 {
+		return style
+	}
+
+	return style.Foreground(tcell.PaletteColor(n - 30))
+}
+
+// visibleLength is the number of runes write draws for text
+func visibleLength(text string) int {
+	length := 0
+	for {
+		start := strings.Index(text, "\033[")
+		if start == -1 {
+			return length + utf8.RuneCountInString(text)
+		}
+
+		length += utf8.RuneCountInString(text[:start])
+		if end := strings.IndexByte(text[start:], 'm'); end != -1 {
+			text = text[start+end+1:]
+		} else {
+			return length
+		}
+	}
+}
+
+// solved returns true when every solution uses the same option of a card
+func solved(solutions []game.Solution, cardIndex int) bool {
+	for _, solution := range solutions {
+		if solution.Verifiers[cardIndex] != solutions[0].Verifiers[cardIndex] {
+			return false
+		}
+	}
+
+	return len(solutions) > 0
+}
+
+// rounds groups the indexes of answers into rounds, the questions about a code in a row up to questionsPerRound at a
+// time, 0 doesn't limit them
+func rounds(answers []game.Answer, questionsPerRound int) [][]int {
+	var played [][]int
+	for i, answer := range answers {
+		last := len(played) - 1
+		if last >= 0 && (questionsPerRound == 0 || len(played[last]) < questionsPerRound) && slices.Equal(answers[played[last][0]].Code, answer.Code) {
+			played[last] = append(played[last], i)
+		} else {
+			played = append(played, []int{i})
+		}
+	}
+
+	return played
+}
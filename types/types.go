@@ -1,11 +1,81 @@
 package types
 
+import "time"
+
 type JoinRequest struct {
 	PlayerName string `json:"playerName"`
+
+	// Lobby is the name of the lobby whose games to play, the server's default lobby when empty
+	Lobby string `json:"lobby,omitempty"`
+
+	// Key is the key registered with the server for the player, or the token of an earlier join to rejoin as the same
+	// player. It can be sent as an Authorization: Bearer header instead.
+	Key string `json:"key,omitempty"`
+}
+
+// JoinResponse has the token to send as an Authorization: Bearer header, and to rejoin with
+type JoinResponse struct {
+	PlayerName string `json:"playerName"`
+	Lobby      string `json:"lobby"`
+	Token      string `json:"token"`
+}
+
+// CreateLobbyRequest creates a lobby with its own games, either the puzzles uploaded or Games new ones generated with
+// NCards verifiers and at least MinSolutions solutions
+type CreateLobbyRequest struct {
+	Name         string   `json:"name"`
+	Puzzles      []Puzzle `json:"puzzles,omitempty"`
+	Games        int      `json:"games,omitempty"`
+	NCards       int      `json:"nCards,omitempty"`
+	MinSolutions int      `json:"minSolutions,omitempty"`
+	Rules        *Rules   `json:"rules,omitempty"`
+}
+
+type LobbyResponse struct {
+	Name    string   `json:"name"`
+	Games   int      `json:"games"`
+	Players []string `json:"players"`
+}
+
+type ListLobbiesResponse struct {
+	Lobbies []LobbyResponse `json:"lobbies"`
+}
+
+// Puzzle is a game with its secret, the secret verifier of each card is its index on the card
+type Puzzle struct {
+	// Cards are the card numbers of the game, XTREAM cards use their combined number
+	Cards     []int `json:"cards"`
+	Verifiers []int `json:"verifiers"`
+	Code      []int `json:"code"`
+
+	// Rules of the game, puzzles without rules are played by the board game's
+	Rules *Rules `json:"rules,omitempty"`
 }
 
 type GetGamesResponse struct {
 	Games [][]int `json:"games"`
+	Rules []Rules `json:"rules"`
+
+	// Answers and Guessed are the answers the player has been given in each game and whether the player has guessed,
+	// for players that rejoin
+	Answers [][]Answer `json:"answers,omitempty"`
+	Guessed []bool     `json:"guessed,omitempty"`
+}
+
+// Answer is a question a player asked and the game's answer
+type Answer struct {
+	Code          []int `json:"code"`
+	VerifierIndex int   `json:"verifierIndex"`
+	Result        bool  `json:"result"`
+}
+
+// Rules are the limits a game puts on the moves of every player
+type Rules struct {
+	// QuestionsPerRound is the most verifiers a player may test the code of a round against, 0 doesn't limit them
+	QuestionsPerRound int `json:"questionsPerRound"`
+
+	// GuessMidRound lets a player guess without ending the round first
+	GuessMidRound bool `json:"guessMidRound"`
 }
 
 type AskQuestionRequest struct {
@@ -14,6 +84,15 @@ type AskQuestionRequest struct {
 	Code          []int `json:"code"`
 }
 
+type StartRoundRequest struct {
+	GameIndex int   `json:"gameIndex"`
+	Code      []int `json:"code"`
+}
+
+type EndRoundRequest struct {
+	GameIndex int `json:"gameIndex"`
+}
+
 type MakeGuessRequest struct {
 	GameIndex int   `json:"gameIndex"`
 	Code      []int `json:"code"`
@@ -23,6 +102,12 @@ type BinaryResponse struct {
 	Result bool `json:"result"`
 }
 
+// ErrorResponse is sent for failed requests, Code names errors of the game package a remote game can return
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
 type RankRequest struct {
 	GameIndex int `json:"gameIndex"`
 }
@@ -38,3 +123,50 @@ type RemotePlayer struct {
 func (p *RemotePlayer) GetPlayerName() string {
 	return p.Name
 }
+
+// DefaultLobby is the lobby of the games a server started with, joined by players that don't name one
+const DefaultLobby = "default"
+
+// Kinds of GameEvent
+const (
+	EventPlayerJoined  = "playerJoined"
+	EventQuestionAsked = "questionAsked"
+	EventGuessMade     = "guessMade"
+	EventRankChanged   = "rankChanged"
+)
+
+// GameEvent is streamed to watchers of a lobby as it happens
+type GameEvent struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+
+	// GameIndex is the game the event happened in, -1 for players joining the lobby
+	GameIndex int    `json:"gameIndex"`
+	Player    string `json:"player,omitempty"`
+
+	// Code, VerifierIndex and Result are the question asked or the guess made. The results of other players' questions
+	// and their guesses are hidden until the game is decided, the events are then sent again with Revealed set.
+	Code          []int `json:"code,omitempty"`
+	VerifierIndex int   `json:"verifierIndex"`
+	Result        *bool `json:"result,omitempty"`
+	Revealed      bool  `json:"revealed,omitempty"`
+
+	// Rankings are the names of the players of the game in order, ties share a place
+	Rankings [][]string `json:"rankings,omitempty"`
+}
+
+// WatchFilter chooses the events of a lobby to watch, GameIndex -1 and an empty Player watch every game and player
+type WatchFilter struct {
+	GameIndex int
+	Player    string
+}
+
+// Matches returns true if the event is one the filter watches
+func (f WatchFilter) Matches(event GameEvent) bool {
+	if f.GameIndex != -1 && event.GameIndex != f.GameIndex {
+		return false
+	}
+
+	// Rankings are about every player of the game
+	return f.Player == "" || event.Player == f.Player || event.Kind == EventRankChanged
+}
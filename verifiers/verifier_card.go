@@ -1,6 +1,12 @@
 package verifiers
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+// xtreamCardNumber is the lowest number of a combined XTREAM card, the lower card number is always first
+const xtreamCardNumber = 1002
 
 type VerifierCard struct {
 	CardNumber int
@@ -502,3 +508,26 @@ func (vc VerifierCard) Combine(other VerifierCard) VerifierCard {
 		Verifiers: verifiers,
 	}
 }
+
+// CardFromNumber returns the card with the number printed on it. XTREAM cards are numbered by both cards with the
+// lower number first, so cards 12 and 34 are 12034.
+func CardFromNumber(cardNumber int) (*VerifierCard, error) {
+	if cardNumber >= xtreamCardNumber {
+		lowNumber := cardNumber / 1000
+		highNumber := cardNumber % 1000
+		if !validCardNumber(lowNumber) || !validCardNumber(highNumber) {
+			return nil, fmt.Errorf("no XTREAM card with number %v: %v : %v", cardNumber, lowNumber, highNumber)
+		}
+
+		xtreamCard := Cards[lowNumber-1].Combine(Cards[highNumber-1])
+		return &xtreamCard, nil
+	} else if !validCardNumber(cardNumber) {
+		return nil, fmt.Errorf("no card with number %v", cardNumber)
+	}
+
+	return &Cards[cardNumber-1], nil
+}
+
+func validCardNumber(cardNumber int) bool {
+	return cardNumber <= len(Cards) && cardNumber > 0
+}
@@ -393,3 +393,20 @@ func TestSummationIsMultipleOf(t *testing.T) {
 		}
 	}
 }
+
+func TestCardFromNumber(t *testing.T) {
+	if card, err := CardFromNumber(4); err != nil || card != &Cards[3] {
+		t.Fatalf("card 4: %v %v", card, err)
+	}
+
+	xtream, err := CardFromNumber(12034)
+	if err != nil || len(xtream.Verifiers) != len(Cards[11].Verifiers)+len(Cards[33].Verifiers) {
+		t.Fatalf("XTREAM card 12034: %v %v", xtream, err)
+	}
+
+	for _, cardNumber := range []int{0, len(Cards) + 1, 99099} {
+		if _, err := CardFromNumber(cardNumber); err == nil {
+			t.Fatalf("expected no card %v", cardNumber)
+		}
+	}
+}